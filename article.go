@@ -32,7 +32,16 @@ type Article struct {
 	Site         bool
 	Pagenum      int
 
-	Assets *Assets
+	Assets    *Assets
+	Images    map[string]*Image
+	Numbering Numbering
+
+	formatErr  error
+	slideNotes []byte
+	columns    int
+	buildSlide bool
+	buildList  bool
+	listBuilds []bool
 }
 
 // Settings define the article settings.
@@ -146,13 +155,6 @@ func (article *Article) Parse(dir string) error {
 			}
 		}
 	}
-	// Process sources.
-	for _, source := range sources {
-		err = article.processFile(dir, source)
-		if err != nil {
-			return err
-		}
-	}
 	article.Name = path.Base(dir)
 	switch article.Name {
 	case ".", "/":
@@ -165,6 +167,14 @@ func (article *Article) Parse(dir string) error {
 		article.Values.SetRaw(ValOutputDir, "../")
 	}
 
+	// Process sources.
+	for _, source := range sources {
+		err = article.processFile(dir, source)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create tags value.
 	for _, tag := range article.Settings.Article.Tags {
 		article.Tags.Add(tag, article)
@@ -227,9 +237,12 @@ func (article *Article) ParseSiteFile(file, section string) error {
 		return err
 	}
 	sectionName := strings.Title(section)
-	sectionData := string(article.format(data))
+	sectionData, err := article.format(data)
+	if err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
 
-	article.Values.SetRaw(sectionName, sectionData)
+	article.Values.SetRaw(sectionName, string(sectionData))
 	return nil
 }
 
@@ -275,9 +288,12 @@ func (article *Article) processFile(dir, file string) error {
 		parts[idx] = strings.Title(part)
 	}
 	sectionName := strings.Join(parts, "")
-	sectionData := string(article.format(data))
+	sectionData, err := article.format(data)
+	if err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
 
-	article.Values.SetRaw(sectionName, sectionData)
+	article.Values.SetRaw(sectionName, string(sectionData))
 	return nil
 }
 
@@ -306,6 +322,10 @@ func (article *Article) Generate(dir string, tmpl *Template) error {
 			return err
 		}
 	}
+	err = article.processImages(path.Join(dir, article.OutputFolder()))
+	if err != nil {
+		return err
+	}
 
 	f, err := os.Create(filename)
 	if err != nil {
@@ -316,7 +336,41 @@ func (article *Article) Generate(dir string, tmpl *Template) error {
 	if !article.Site && article.Name == "index" {
 		return tmpl.Templates[TmplIndex].Execute(f, article.Values)
 	}
-	return tmpl.Templates[article.Type()].Execute(f, article.Values)
+	err = tmpl.Templates[article.Type()].Execute(f, article.Values)
+	if err != nil {
+		return err
+	}
+	if article.Type() != TmplPresentation {
+		return nil
+	}
+	err = article.GenerateHandout(dir, tmpl)
+	if err != nil {
+		return err
+	}
+	if flagStandalone {
+		if err = f.Close(); err != nil {
+			return err
+		}
+		return article.GenerateStandalone(dir)
+	}
+	return nil
+}
+
+// GenerateHandout generates the printable presentation handout to
+// the argument directory. The handout is generated only if the
+// output template defines the handout template.
+func (article *Article) GenerateHandout(dir string, tmpl *Template) error {
+	t, ok := tmpl.Templates[TmplHandout]
+	if !ok {
+		return nil
+	}
+	f, err := os.Create(path.Join(dir, article.outputName("-handout.html")))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return t.Execute(f, article.Values)
 }
 
 // OutputFolder returns the article output folder name.
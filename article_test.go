@@ -0,0 +1,132 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown/parser"
+)
+
+var presentationSource = `# Talk
+
+## First {bg=photo.png}
+
+![Photo](photo.png)
+
+` + "```notes\nRemember the *photo*.\n```" + `
+
+## Second
+
+Text.
+`
+
+var (
+	reFileReference = regexp.MustCompile(
+		`(src|href|srcset)="([^"]*)"|(url)\(\s*['"]?([^'")]*)`)
+	reInlineScript = regexp.MustCompile(`(?s)<script>.*?</script>`)
+)
+
+func TestGeneratePresentation(t *testing.T) {
+	savedTmpl := tmpl
+	savedStandalone := flagStandalone
+	defer func() {
+		tmpl = savedTmpl
+		flagStandalone = savedStandalone
+	}()
+
+	var err error
+	tmpl, err = loadTemplate("templates/mtr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagStandalone = true
+
+	dir := path.Join(t.TempDir(), "talk")
+	out := t.TempDir()
+	err = os.Mkdir(dir, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestPNG(t, path.Join(dir, "photo.png"), 1000, 10)
+	err = os.WriteFile(path.Join(dir, "settings.toml"), []byte(`[article]
+title = "Talk"
+type = "presentation"
+published = 2024-01-02T00:00:00Z
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path.Join(dir, "column-article.md"),
+		[]byte(presentationSource), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	article := NewArticle(parser.CommonExtensions)
+	err = article.Parse(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tmpl.CopyAssets(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = article.Generate(out, tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The handout has the slides and their speaker notes.
+	data, err := os.ReadFile(path.Join(out, "2024-01-02/talk-handout.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []string{
+		"<h3>First</h3>",
+		"<h3>Second</h3>",
+		`<aside class="notes">`,
+		"<em>photo</em>",
+	} {
+		if !strings.Contains(string(data), e) {
+			t.Errorf("handout: %q not in output", e)
+		}
+	}
+
+	// The standalone presentation does not reference other files.
+	data, err = os.ReadFile(path.Join(out, "2024-01-02/talk-standalone.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := reInlineScript.ReplaceAllString(string(data), "")
+	for _, m := range reFileReference.FindAllStringSubmatch(html, -1) {
+		kind := m[1] + m[3]
+		ref := m[2] + m[4]
+		if kind == "href" && isExternal(ref) {
+			// Links and in-document references.
+			continue
+		}
+		if !strings.HasPrefix(ref, "data:") {
+			t.Errorf("standalone: %s reference to %q", kind, ref)
+		}
+	}
+	for _, e := range []string{
+		"<script>",
+		"<style>",
+		"url('data:font/woff2;base64,",
+		"background-image: url('data:image/png;base64,",
+		`<img src="data:image/png;base64,`,
+	} {
+		if !strings.Contains(string(data), e) {
+			t.Errorf("standalone: %q not in output", e)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"strings"
+)
+
+// Dashed line runes.
+const (
+	dashedHorizontal rune = 0x254C // ╌
+	dottedHorizontal rune = 0x2508 // ┈
+	dottedVertical   rune = 0x250A // ┊
+)
+
+// dashStyles defines the SVG line styles of the dashed box-drawing
+// characters.
+var dashStyles = map[rune]lineStyle{
+	0x2504: styleDashed,
+	0x2505: styleDashed,
+	0x2506: styleDashed,
+	0x2507: styleDashed,
+	0x2508: styleDotted,
+	0x2509: styleDotted,
+	0x250A: styleDotted,
+	0x250B: styleDotted,
+	0x254C: styleDashed,
+	0x254D: styleDashed,
+	0x254E: styleDashed,
+	0x254F: styleDashed,
+}
+
+// markDashed replaces the dashed lines of the region with the dashed
+// box-drawing characters so that they connect to the corners and
+// junctions like the solid lines. The dashed lines are:
+//
+//   - horizontal runs of minus signs separated by single spaces (- - -).
+//     The runs continue to the arrowheads following them after a
+//     space (- - >).
+//   - horizontal runs of periods connected to a line, corner, or an
+//     arrowhead at either end (+.....>)
+//   - vertical runs of two or more colons connected to a line, corner,
+//     or an arrowhead at either end, or single colons connected at
+//     both ends
+func (r *Region) markDashed() {
+	for row := 0; row < r.Height(); row++ {
+		for col := 0; col < r.Width(); col++ {
+			switch r.Get(row, col) {
+			case '-':
+				end := col
+				for r.Get(row, end+1) == ' ' && r.Get(row, end+2) == '-' {
+					end += 2
+				}
+				if end > col {
+					start := col
+					if r.Get(row, start-1) == ' ' && r.Get(row, start-2) == '<' {
+						start--
+					}
+					if r.Get(row, end+1) == ' ' && r.Get(row, end+2) == '>' {
+						end++
+					}
+					r.fill(row, start, 0, 1, end-start+1, dashedHorizontal)
+				}
+				col = end
+
+			case '.':
+				end := col
+				for r.Get(row, end+1) == '.' {
+					end++
+				}
+				if end > col && (connector(r.Get(row, col-1), "<>") ||
+					connector(r.Get(row, end+1), "<>")) {
+					r.fill(row, col, 0, 1, end-col+1, dottedHorizontal)
+				}
+				col = end
+			}
+		}
+	}
+	for col := 0; col < r.Width(); col++ {
+		for row := 0; row < r.Height(); row++ {
+			if r.Get(row, col) != ':' {
+				continue
+			}
+			end := row
+			for r.Get(end+1, col) == ':' {
+				end++
+			}
+			above := connector(r.Get(row-1, col), "^vV")
+			below := connector(r.Get(end+1, col), "^vV")
+			if (end > row && (above || below)) || (above && below) {
+				r.fill(row, col, 1, 0, end-row+1, dottedVertical)
+			}
+			row = end
+		}
+	}
+}
+
+// fill sets count cells starting from row, col to ch. The dr and dc
+// specify the fill direction.
+func (r *Region) fill(row, col, dr, dc, count int, ch rune) {
+	for i := 0; i < count; i++ {
+		r.Set(row+i*dr, col+i*dc, ch)
+	}
+}
+
+// connector tests if the character ch is a line, corner, or one of
+// the arrowhead characters which can end a dashed line.
+func connector(ch rune, arrows string) bool {
+	return properties[ch] != 0 || strings.ContainsRune(arrows, ch)
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"testing"
+)
+
+func TestProcessDashed(t *testing.T) {
+	testConvert(t, "Process", Process, []processTest{
+		{
+			input: `
++- - -+
+:     :
++.....+`,
+			output: `
+┌╌╌╌╌╌┐
+┊     ┊
+└┈┈┈┈┈┘`,
+		},
+		{
+			// The dashed lines continue to the arrowheads.
+			input: `
+a - - > b
+c < - - d`,
+			output: `
+a ╌╌╌╌► b
+c ◄╌╌╌╌ d`,
+		},
+		{
+			input: `
++---+
+|   |
++---+
+  :
+  :
+  v`,
+			output: `
+┌───┐
+│   │
+└───┘
+  ┊
+  ┊
+  ▼`,
+		},
+		{
+			input: `
+x ...... y
++......>`,
+			output: `
+x ...... y
+╶┈┈┈┈┈┈►`,
+		},
+	})
+}
+
+func TestProcessDashedLabels(t *testing.T) {
+	// The colons of text labels are not dotted lines.
+	testConvert(t, "Process", Process, []processTest{
+		{
+			input: `
+host: a
+port: 80`,
+			output: `
+host: a
+port: 80`,
+		},
+	})
+}
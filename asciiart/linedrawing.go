@@ -8,12 +8,26 @@ package asciiart
 
 import (
 	"strings"
+	"unicode"
 )
 
-// Region defines an ASCII art region.
+// Region defines an ASCII art region. The region columns are display
+// cells of a monospace font: the wide characters occupy two cells and
+// the zero-width marks are combined with the preceding character. The
+// emoji sequences are combined into their first emoji, see
+// clusterWidth.
 type Region struct {
 	maxWidth int
-	lines    [][]rune
+	lines    [][]cell
+}
+
+// cell defines a region cell. The cont specifies the second cell of a
+// wide character and the marks contain the zero-width marks
+// following the character ch.
+type cell struct {
+	ch    rune
+	marks string
+	cont  bool
 }
 
 func (r *Region) String() string {
@@ -23,14 +37,14 @@ func (r *Region) String() string {
 		if row > 0 {
 			b.WriteRune('\n')
 		}
-		for _, r := range line {
-			b.WriteRune(r)
+		for col := range line {
+			b.WriteString(r.text(row, col))
 		}
 	}
 	return b.String()
 }
 
-// Width returns the width of the region in characters.
+// Width returns the width of the region in display cells.
 func (r *Region) Width() int {
 	return r.maxWidth
 }
@@ -40,7 +54,8 @@ func (r *Region) Height() int {
 	return len(r.lines)
 }
 
-// Get the rune from the specified coordinate.
+// Get the rune from the specified coordinate. The function returns 0
+// for the second cells of wide characters.
 func (r *Region) Get(row, col int) rune {
 	if row < 0 || row >= len(r.lines) {
 		return 0
@@ -48,25 +63,55 @@ func (r *Region) Get(row, col int) rune {
 	if col < 0 || col >= len(r.lines[row]) {
 		return 0
 	}
-	return r.lines[row][col]
+	return r.lines[row][col].ch
 }
 
-// Set the run in the specified coordinate.
+// Set the run in the specified coordinate. The second cells of wide
+// characters can't be set.
 func (r *Region) Set(row, col int, ch rune) {
 	if row < 0 || row >= len(r.lines) {
 		return
 	}
-	if col < 0 || col >= len(r.lines[row]) {
+	if col < 0 || col >= len(r.lines[row]) || r.lines[row][col].cont {
 		return
 	}
-	r.lines[row][col] = ch
+	r.lines[row][col].ch = ch
+}
+
+// text returns the text of the cell: the character and its zero-width
+// marks. The function returns an empty string for the second cells of
+// wide characters.
+func (r *Region) text(row, col int) string {
+	if row < 0 || row >= len(r.lines) {
+		return ""
+	}
+	if col < 0 || col >= len(r.lines[row]) {
+		return ""
+	}
+	c := r.lines[row][col]
+	if c.cont {
+		return ""
+	}
+	return string(c.ch) + c.marks
+}
+
+// continuation tests if the cell is the second cell of a wide
+// character.
+func (r *Region) continuation(row, col int) bool {
+	if row < 0 || row >= len(r.lines) {
+		return false
+	}
+	if col < 0 || col >= len(r.lines[row]) {
+		return false
+	}
+	return r.lines[row][col].cont
 }
 
 // Clone creates a copy of the region.
 func (r *Region) Clone() *Region {
-	lines := make([][]rune, len(r.lines))
+	lines := make([][]cell, len(r.lines))
 	for i, line := range r.lines {
-		l := make([]rune, len(line))
+		l := make([]cell, len(line))
 		copy(l, line)
 		lines[i] = l
 	}
@@ -78,15 +123,47 @@ func (r *Region) Clone() *Region {
 
 // NewRegion creates a region of the input data.
 func NewRegion(input []byte) *Region {
-	var lines [][]rune
+	var lines [][]cell
 	var width int
 
 	for _, line := range strings.Split(string(input), "\n") {
-		runes := []rune(line)
-		if len(runes) > width {
-			width = len(runes)
+		var cells []cell
+		var prev rune
+		for _, r := range line {
+			cw := clusterWidth(prev, r)
+			prev = r
+			switch cw {
+			case 0:
+				if len(cells) > 0 {
+					last := len(cells) - 1
+					if cells[last].cont {
+						last--
+					}
+					cells[last].marks += string(r)
+					continue
+				}
+				// Leading mark has no base character.
+				cells = append(cells, cell{
+					ch: r,
+				})
+
+			case 2:
+				cells = append(cells, cell{
+					ch: r,
+				}, cell{
+					cont: true,
+				})
+
+			default:
+				cells = append(cells, cell{
+					ch: r,
+				})
+			}
+		}
+		if len(cells) > width {
+			width = len(cells)
 		}
-		lines = append(lines, runes)
+		lines = append(lines, cells)
 	}
 	return &Region{
 		maxWidth: width,
@@ -100,8 +177,49 @@ const (
 	FlagDown
 	FlagLeft
 	FlagRight
+	FlagUpLeft
+	FlagUpRight
+	FlagDownLeft
+	FlagDownRight
 )
 
+// Diagonal connection directions.
+const diagonals = FlagUpLeft | FlagUpRight | FlagDownLeft | FlagDownRight
+
+// directions lists all connection directions.
+var directions = []int{
+	FlagUp, FlagDown, FlagLeft, FlagRight,
+	FlagUpLeft, FlagUpRight, FlagDownLeft, FlagDownRight,
+}
+
+// Line style flags. The style flags of a direction are its connection
+// direction flag shifted to the style's bits. The lines without style
+// flags are light lines.
+const (
+	heavyShift  = 8
+	doubleShift = 12
+
+	heavyUp    = FlagUp << heavyShift
+	heavyDown  = FlagDown << heavyShift
+	heavyLeft  = FlagLeft << heavyShift
+	heavyRight = FlagRight << heavyShift
+
+	doubleUp    = FlagUp << doubleShift
+	doubleDown  = FlagDown << doubleShift
+	doubleLeft  = FlagLeft << doubleShift
+	doubleRight = FlagRight << doubleShift
+
+	heavyVertical    = heavyUp | heavyDown
+	heavyHorizontal  = heavyLeft | heavyRight
+	doubleVertical   = doubleUp | doubleDown
+	doubleHorizontal = doubleLeft | doubleRight
+
+	// directionFlags masks the connection direction flags.
+	directionFlags = 1<<heavyShift - 1
+)
+
+// properties define the connection directions and the line styles of
+// the line drawing characters.
 var properties = map[rune]int{
 	'+': FlagUp | FlagDown | FlagLeft | FlagRight,
 	'*': FlagUp | FlagDown | FlagLeft | FlagRight,
@@ -109,140 +227,140 @@ var properties = map[rune]int{
 	'-': FlagLeft | FlagRight,
 
 	0x2500: FlagLeft | FlagRight,
-	0x2501: FlagLeft | FlagRight,
+	0x2501: FlagLeft | FlagRight | heavyHorizontal,
 	0x2502: FlagDown | FlagUp,
-	0x2503: FlagDown | FlagUp,
+	0x2503: FlagDown | FlagUp | heavyVertical,
 	0x2504: FlagLeft | FlagRight,
-	0x2505: FlagLeft | FlagRight,
+	0x2505: FlagLeft | FlagRight | heavyHorizontal,
 	0x2506: FlagDown | FlagUp,
-	0x2507: FlagDown | FlagUp,
+	0x2507: FlagDown | FlagUp | heavyVertical,
 	0x2508: FlagLeft | FlagRight,
-	0x2509: FlagLeft | FlagRight,
+	0x2509: FlagLeft | FlagRight | heavyHorizontal,
 	0x250A: FlagDown | FlagUp,
-	0x250B: FlagDown | FlagUp,
+	0x250B: FlagDown | FlagUp | heavyVertical,
 	0x250C: FlagDown | FlagRight,
-	0x250D: FlagDown | FlagRight,
-	0x250E: FlagDown | FlagRight,
-	0x250F: FlagDown | FlagRight,
+	0x250D: FlagDown | FlagRight | heavyRight,
+	0x250E: FlagDown | FlagRight | heavyDown,
+	0x250F: FlagDown | FlagRight | heavyDown | heavyRight,
 
 	0x2510: FlagDown | FlagLeft,
-	0x2511: FlagDown | FlagLeft,
-	0x2512: FlagDown | FlagLeft,
-	0x2513: FlagDown | FlagLeft,
+	0x2511: FlagDown | FlagLeft | heavyLeft,
+	0x2512: FlagDown | FlagLeft | heavyDown,
+	0x2513: FlagDown | FlagLeft | heavyDown | heavyLeft,
 	0x2514: FlagUp | FlagRight,
-	0x2515: FlagUp | FlagRight,
-	0x2516: FlagUp | FlagRight,
-	0x2517: FlagUp | FlagRight,
+	0x2515: FlagUp | FlagRight | heavyRight,
+	0x2516: FlagUp | FlagRight | heavyUp,
+	0x2517: FlagUp | FlagRight | heavyUp | heavyRight,
 	0x2518: FlagUp | FlagLeft,
-	0x2519: FlagUp | FlagLeft,
-	0x251A: FlagUp | FlagLeft,
-	0x251B: FlagUp | FlagLeft,
+	0x2519: FlagUp | FlagLeft | heavyLeft,
+	0x251A: FlagUp | FlagLeft | heavyUp,
+	0x251B: FlagUp | FlagLeft | heavyUp | heavyLeft,
 	0x251C: FlagUp | FlagDown | FlagRight,
-	0x251D: FlagUp | FlagDown | FlagRight,
-	0x251E: FlagUp | FlagDown | FlagRight,
-	0x251F: FlagUp | FlagDown | FlagRight,
-
-	0x2520: FlagUp | FlagDown | FlagRight,
-	0x2521: FlagUp | FlagDown | FlagRight,
-	0x2522: FlagUp | FlagDown | FlagRight,
-	0x2523: FlagUp | FlagDown | FlagRight,
+	0x251D: FlagUp | FlagDown | FlagRight | heavyRight,
+	0x251E: FlagUp | FlagDown | FlagRight | heavyUp,
+	0x251F: FlagUp | FlagDown | FlagRight | heavyDown,
+
+	0x2520: FlagUp | FlagDown | FlagRight | heavyVertical,
+	0x2521: FlagUp | FlagDown | FlagRight | heavyUp | heavyRight,
+	0x2522: FlagUp | FlagDown | FlagRight | heavyDown | heavyRight,
+	0x2523: FlagUp | FlagDown | FlagRight | heavyVertical | heavyRight,
 	0x2524: FlagUp | FlagDown | FlagLeft,
-	0x2525: FlagUp | FlagDown | FlagLeft,
-	0x2526: FlagUp | FlagDown | FlagLeft,
-	0x2527: FlagUp | FlagDown | FlagLeft,
-	0x2528: FlagUp | FlagDown | FlagLeft,
-	0x2529: FlagUp | FlagDown | FlagLeft,
-	0x252A: FlagUp | FlagDown | FlagLeft,
-	0x252B: FlagUp | FlagDown | FlagLeft,
+	0x2525: FlagUp | FlagDown | FlagLeft | heavyLeft,
+	0x2526: FlagUp | FlagDown | FlagLeft | heavyUp,
+	0x2527: FlagUp | FlagDown | FlagLeft | heavyDown,
+	0x2528: FlagUp | FlagDown | FlagLeft | heavyVertical,
+	0x2529: FlagUp | FlagDown | FlagLeft | heavyUp | heavyLeft,
+	0x252A: FlagUp | FlagDown | FlagLeft | heavyDown | heavyLeft,
+	0x252B: FlagUp | FlagDown | FlagLeft | heavyVertical | heavyLeft,
 	0x252C: FlagDown | FlagLeft | FlagRight,
-	0x252D: FlagDown | FlagLeft | FlagRight,
-	0x252E: FlagDown | FlagLeft | FlagRight,
-	0x252F: FlagDown | FlagLeft | FlagRight,
-
-	0x2530: FlagDown | FlagLeft | FlagRight,
-	0x2531: FlagDown | FlagLeft | FlagRight,
-	0x2532: FlagDown | FlagLeft | FlagRight,
-	0x2533: FlagDown | FlagLeft | FlagRight,
+	0x252D: FlagDown | FlagLeft | FlagRight | heavyLeft,
+	0x252E: FlagDown | FlagLeft | FlagRight | heavyRight,
+	0x252F: FlagDown | FlagLeft | FlagRight | heavyHorizontal,
+
+	0x2530: FlagDown | FlagLeft | FlagRight | heavyDown,
+	0x2531: FlagDown | FlagLeft | FlagRight | heavyDown | heavyLeft,
+	0x2532: FlagDown | FlagLeft | FlagRight | heavyDown | heavyRight,
+	0x2533: FlagDown | FlagLeft | FlagRight | heavyHorizontal | heavyDown,
 	0x2534: FlagUp | FlagLeft | FlagRight,
-	0x2535: FlagUp | FlagLeft | FlagRight,
-	0x2536: FlagUp | FlagLeft | FlagRight,
-	0x2537: FlagUp | FlagLeft | FlagRight,
-	0x2538: FlagUp | FlagLeft | FlagRight,
-	0x2539: FlagUp | FlagLeft | FlagRight,
-	0x253A: FlagUp | FlagLeft | FlagRight,
-	0x253B: FlagUp | FlagLeft | FlagRight,
+	0x2535: FlagUp | FlagLeft | FlagRight | heavyLeft,
+	0x2536: FlagUp | FlagLeft | FlagRight | heavyRight,
+	0x2537: FlagUp | FlagLeft | FlagRight | heavyHorizontal,
+	0x2538: FlagUp | FlagLeft | FlagRight | heavyUp,
+	0x2539: FlagUp | FlagLeft | FlagRight | heavyUp | heavyLeft,
+	0x253A: FlagUp | FlagLeft | FlagRight | heavyUp | heavyRight,
+	0x253B: FlagUp | FlagLeft | FlagRight | heavyHorizontal | heavyUp,
 	0x253C: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x253D: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x253E: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x253F: FlagUp | FlagDown | FlagLeft | FlagRight,
-
-	0x2540: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2541: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2542: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2543: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2544: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2545: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2546: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2547: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2548: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x2549: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x254A: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x254B: FlagUp | FlagDown | FlagLeft | FlagRight,
+	0x253D: FlagUp | FlagDown | FlagLeft | FlagRight | heavyLeft,
+	0x253E: FlagUp | FlagDown | FlagLeft | FlagRight | heavyRight,
+	0x253F: FlagUp | FlagDown | FlagLeft | FlagRight | heavyHorizontal,
+
+	0x2540: FlagUp | FlagDown | FlagLeft | FlagRight | heavyUp,
+	0x2541: FlagUp | FlagDown | FlagLeft | FlagRight | heavyDown,
+	0x2542: FlagUp | FlagDown | FlagLeft | FlagRight | heavyVertical,
+	0x2543: FlagUp | FlagDown | FlagLeft | FlagRight | heavyUp | heavyLeft,
+	0x2544: FlagUp | FlagDown | FlagLeft | FlagRight | heavyUp | heavyRight,
+	0x2545: FlagUp | FlagDown | FlagLeft | FlagRight | heavyDown | heavyLeft,
+	0x2546: FlagUp | FlagDown | FlagLeft | FlagRight | heavyDown | heavyRight,
+	0x2547: FlagUp | FlagDown | FlagLeft | FlagRight | heavyHorizontal | heavyUp,
+	0x2548: FlagUp | FlagDown | FlagLeft | FlagRight | heavyHorizontal | heavyDown,
+	0x2549: FlagUp | FlagDown | FlagLeft | FlagRight | heavyVertical | heavyLeft,
+	0x254A: FlagUp | FlagDown | FlagLeft | FlagRight | heavyVertical | heavyRight,
+	0x254B: FlagUp | FlagDown | FlagLeft | FlagRight | heavyVertical | heavyHorizontal,
 	0x254C: FlagLeft | FlagRight,
-	0x254D: FlagLeft | FlagRight,
+	0x254D: FlagLeft | FlagRight | heavyHorizontal,
 	0x254E: FlagUp | FlagDown,
-	0x254F: FlagUp | FlagDown,
-
-	0x2550: FlagLeft | FlagRight,
-	0x2551: FlagUp | FlagDown,
-	0x2552: FlagDown | FlagRight,
-	0x2553: FlagDown | FlagRight,
-	0x2554: FlagDown | FlagRight,
-	0x2555: FlagDown | FlagLeft,
-	0x2556: FlagDown | FlagLeft,
-	0x2557: FlagDown | FlagLeft,
-	0x2558: FlagUp | FlagRight,
-	0x2559: FlagUp | FlagRight,
-	0x255A: FlagUp | FlagRight,
-	0x255B: FlagUp | FlagLeft,
-	0x255C: FlagUp | FlagLeft,
-	0x255D: FlagUp | FlagLeft,
-	0x255E: FlagUp | FlagDown | FlagRight,
-	0x255F: FlagUp | FlagDown | FlagRight,
-
-	0x2560: FlagUp | FlagDown | FlagRight,
-	0x2561: FlagUp | FlagDown | FlagLeft,
-	0x2562: FlagUp | FlagDown | FlagLeft,
-	0x2563: FlagUp | FlagDown | FlagLeft,
-	0x2564: FlagDown | FlagLeft | FlagRight,
-	0x2565: FlagDown | FlagLeft | FlagRight,
-	0x2566: FlagDown | FlagLeft | FlagRight,
-	0x2567: FlagUp | FlagLeft | FlagRight,
-	0x2568: FlagUp | FlagLeft | FlagRight,
-	0x2569: FlagUp | FlagLeft | FlagRight,
-	0x256A: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x256B: FlagUp | FlagDown | FlagLeft | FlagRight,
-	0x256C: FlagUp | FlagDown | FlagLeft | FlagRight,
+	0x254F: FlagUp | FlagDown | heavyVertical,
+
+	0x2550: FlagLeft | FlagRight | doubleHorizontal,
+	0x2551: FlagUp | FlagDown | doubleVertical,
+	0x2552: FlagDown | FlagRight | doubleRight,
+	0x2553: FlagDown | FlagRight | doubleDown,
+	0x2554: FlagDown | FlagRight | doubleDown | doubleRight,
+	0x2555: FlagDown | FlagLeft | doubleLeft,
+	0x2556: FlagDown | FlagLeft | doubleDown,
+	0x2557: FlagDown | FlagLeft | doubleDown | doubleLeft,
+	0x2558: FlagUp | FlagRight | doubleRight,
+	0x2559: FlagUp | FlagRight | doubleUp,
+	0x255A: FlagUp | FlagRight | doubleUp | doubleRight,
+	0x255B: FlagUp | FlagLeft | doubleLeft,
+	0x255C: FlagUp | FlagLeft | doubleUp,
+	0x255D: FlagUp | FlagLeft | doubleUp | doubleLeft,
+	0x255E: FlagUp | FlagDown | FlagRight | doubleRight,
+	0x255F: FlagUp | FlagDown | FlagRight | doubleVertical,
+
+	0x2560: FlagUp | FlagDown | FlagRight | doubleVertical | doubleRight,
+	0x2561: FlagUp | FlagDown | FlagLeft | doubleLeft,
+	0x2562: FlagUp | FlagDown | FlagLeft | doubleVertical,
+	0x2563: FlagUp | FlagDown | FlagLeft | doubleVertical | doubleLeft,
+	0x2564: FlagDown | FlagLeft | FlagRight | doubleHorizontal,
+	0x2565: FlagDown | FlagLeft | FlagRight | doubleDown,
+	0x2566: FlagDown | FlagLeft | FlagRight | doubleHorizontal | doubleDown,
+	0x2567: FlagUp | FlagLeft | FlagRight | doubleHorizontal,
+	0x2568: FlagUp | FlagLeft | FlagRight | doubleUp,
+	0x2569: FlagUp | FlagLeft | FlagRight | doubleHorizontal | doubleUp,
+	0x256A: FlagUp | FlagDown | FlagLeft | FlagRight | doubleHorizontal,
+	0x256B: FlagUp | FlagDown | FlagLeft | FlagRight | doubleVertical,
+	0x256C: FlagUp | FlagDown | FlagLeft | FlagRight | doubleVertical | doubleHorizontal,
 	0x256D: FlagDown | FlagRight,
 	0x256E: FlagDown | FlagLeft,
 	0x256F: FlagUp | FlagLeft,
 
 	0x2570: FlagUp | FlagRight,
-	0x2571: 0,
-	0x2572: 0,
-	0x2573: 0,
+	0x2571: FlagUpRight | FlagDownLeft,
+	0x2572: FlagUpLeft | FlagDownRight,
+	0x2573: FlagUpLeft | FlagUpRight | FlagDownLeft | FlagDownRight,
 	0x2574: FlagLeft,
 	0x2575: FlagUp,
 	0x2576: FlagRight,
 	0x2577: FlagDown,
-	0x2578: FlagLeft,
-	0x2579: FlagUp,
-	0x257A: FlagRight,
-	0x257B: FlagDown,
-	0x257C: FlagLeft | FlagRight,
-	0x257D: FlagUp | FlagDown,
-	0x257E: FlagLeft | FlagRight,
-	0x257F: FlagUp | FlagDown,
+	0x2578: FlagLeft | heavyLeft,
+	0x2579: FlagUp | heavyUp,
+	0x257A: FlagRight | heavyRight,
+	0x257B: FlagDown | heavyDown,
+	0x257C: FlagLeft | FlagRight | heavyRight,
+	0x257D: FlagUp | FlagDown | heavyDown,
+	0x257E: FlagLeft | FlagRight | heavyLeft,
+	0x257F: FlagUp | FlagDown | heavyUp,
 }
 
 var lineDrawing = []rune{
@@ -321,6 +439,226 @@ var slash = []rune{
 	0x253C, // Right Left Down Up	┼
 }
 
+// Arrowhead runes by their directions.
+var arrowheads = map[int]rune{
+	FlagUp:    0x25B2, // ▲
+	FlagDown:  0x25BC, // ▼
+	FlagLeft:  0x25C4, // ◄
+	FlagRight: 0x25BA, // ►
+}
+
+// Arrowhead directions by their runes.
+var arrowheadDirs = map[rune]int{
+	0x25B2: FlagUp,
+	0x25BC: FlagDown,
+	0x25C4: FlagLeft,
+	0x25BA: FlagRight,
+}
+
+// neighbor returns the coordinates of the neighbor cell in the
+// direction flag.
+func neighbor(row, col, flag int) (int, int) {
+	if flag&(FlagUp|FlagUpLeft|FlagUpRight) != 0 {
+		row--
+	}
+	if flag&(FlagDown|FlagDownLeft|FlagDownRight) != 0 {
+		row++
+	}
+	if flag&(FlagLeft|FlagUpLeft|FlagDownLeft) != 0 {
+		col--
+	}
+	if flag&(FlagRight|FlagUpRight|FlagDownRight) != 0 {
+		col++
+	}
+	return row, col
+}
+
+// diagonal tests if the cell is a part of a diagonal line of at least
+// two slashes or backslashes, or a crossing (X) of two diagonal
+// lines. The function returns the diagonal line rune or 0 if the cell
+// is not a diagonal line.
+func (r *Region) diagonal(row, col int) rune {
+	rising := func(ch rune) bool {
+		return ch == '/' || ch == 'X' || ch == 'x'
+	}
+	falling := func(ch rune) bool {
+		return ch == '\\' || ch == 'X' || ch == 'x'
+	}
+	switch r.Get(row, col) {
+	case '/':
+		if rising(r.Get(row-1, col+1)) || rising(r.Get(row+1, col-1)) {
+			return 0x2571
+		}
+	case '\\':
+		if falling(r.Get(row-1, col-1)) || falling(r.Get(row+1, col+1)) {
+			return 0x2572
+		}
+	case 'X', 'x':
+		if r.Get(row-1, col+1) == '/' && r.Get(row+1, col-1) == '/' &&
+			r.Get(row-1, col-1) == '\\' && r.Get(row+1, col+1) == '\\' {
+			return 0x2573
+		}
+	}
+	return 0
+}
+
+// styledLine returns the double (=) or heavy (#) line character for
+// the cell. The function returns 0 if the cell is not a line, see
+// styledFlags.
+func (r *Region) styledLine(row, col int) rune {
+	style := asciiStyle(r.Get(row, col))
+	flags := r.styledFlags(row, col)
+	if flags&(FlagLeft|FlagRight) != 0 {
+		return styledRunes[[4]lineStyle{styleNone, styleNone, style, style}]
+	}
+	if flags&(FlagUp|FlagDown) != 0 {
+		return styledRunes[[4]lineStyle{style, style, styleNone, styleNone}]
+	}
+	return 0
+}
+
+// styledFlags returns the connection directions of the double (=) or
+// heavy (#) line cell. The equal and number signs are lines only if
+// their runs end at lines, corners, or junctions so that the signs of
+// text labels, such as key=value, a == b, and #12, are not taken as
+// lines. The horizontal runs must have at least two signs and end at
+// a line or an arrowhead. The vertical runs must have at least two
+// signs and end at a corner (+, *). The single signs are lines if
+// they connect at both ends. The signs next to letters or digits are
+// not lines. The function returns 0 if the cell is not a line.
+func (r *Region) styledFlags(row, col int) int {
+	ch := r.Get(row, col)
+	if ch != '=' && ch != '#' {
+		return 0
+	}
+
+	start, end := col, col
+	for r.Get(row, start-1) == ch {
+		start--
+	}
+	for r.Get(row, end+1) == ch {
+		end++
+	}
+	left := r.Get(row, start-1)
+	right := r.Get(row, end+1)
+	leftEnd := isLine(left, FlagRight) || left == '<'
+	rightEnd := isLine(right, FlagLeft) || right == '>'
+	if ((end > start && (leftEnd || rightEnd)) || (leftEnd && rightEnd)) &&
+		!r.inWord(row, start) && !r.inWord(row, end) {
+		return FlagLeft | FlagRight
+	}
+
+	top, bottom := row, row
+	for r.Get(top-1, col) == ch {
+		top--
+	}
+	for r.Get(bottom+1, col) == ch {
+		bottom++
+	}
+	above := isCorner(r.Get(top-1, col))
+	below := isCorner(r.Get(bottom+1, col))
+	if !(bottom > top && (above || below)) && !(above && below) {
+		return 0
+	}
+	for i := top; i <= bottom; i++ {
+		if r.inWord(i, col) {
+			return 0
+		}
+	}
+	return FlagUp | FlagDown
+}
+
+// isCorner tests if the character is an ASCII corner or junction.
+func isCorner(ch rune) bool {
+	return ch == '+' || ch == '*'
+}
+
+// diagonalJoins returns the vertical directions of the diagonal lines
+// ending at the cell. The box-drawing characters do not have diagonal
+// junctions so the diagonal lines join corners and lines vertically.
+func (r *Region) diagonalJoins(row, col int) int {
+	var index int
+	if r.diagonal(row-1, col-1) == 0x2572 || r.diagonal(row-1, col+1) == 0x2571 {
+		index |= FlagUp
+	}
+	if r.diagonal(row+1, col-1) == 0x2571 || r.diagonal(row+1, col+1) == 0x2572 {
+		index |= FlagDown
+	}
+	return index
+}
+
+// opposite returns the opposite direction of the direction flag.
+func opposite(flag int) int {
+	switch flag {
+	case FlagUp:
+		return FlagDown
+	case FlagDown:
+		return FlagUp
+	case FlagLeft:
+		return FlagRight
+	case FlagRight:
+		return FlagLeft
+	case FlagUpLeft:
+		return FlagDownRight
+	case FlagUpRight:
+		return FlagDownLeft
+	case FlagDownLeft:
+		return FlagUpRight
+	case FlagDownRight:
+		return FlagUpLeft
+	default:
+		return 0
+	}
+}
+
+// arrowhead tests if the cell contains an ASCII arrowhead (>, <, ^,
+// v) at the end of a line. The function returns the direction flag of
+// the arrowhead or 0 if the cell is not an arrowhead. The vertical
+// arrowheads must not be adjacent to letters or digits so that the
+// letters of text labels are not taken as arrowheads.
+func (r *Region) arrowhead(row, col int) int {
+	switch r.Get(row, col) {
+	case '>':
+		if isLine(r.Get(row, col-1), FlagRight) ||
+			r.styledFlags(row, col-1)&FlagRight != 0 {
+			return FlagRight
+		}
+	case '<':
+		if isLine(r.Get(row, col+1), FlagLeft) ||
+			r.styledFlags(row, col+1)&FlagLeft != 0 {
+			return FlagLeft
+		}
+	case '^':
+		if isLine(r.Get(row+1, col), FlagUp) && !r.inWord(row, col) {
+			return FlagUp
+		}
+	case 'v', 'V':
+		if isLine(r.Get(row-1, col), FlagDown) && !r.inWord(row, col) {
+			return FlagDown
+		}
+	}
+	return 0
+}
+
+func (r *Region) inWord(row, col int) bool {
+	for _, ch := range []rune{r.Get(row, col-1), r.Get(row, col+1)} {
+		if unicode.IsLetter(ch) || unicode.IsDigit(ch) {
+			return true
+		}
+	}
+	return false
+}
+
+// connects tests if the cell connects to the direction flag. The
+// arrowheads connect to the opposite direction of their points.
+func (r *Region) connects(row, col, flag int) bool {
+	if isLine(r.Get(row, col), flag) || r.styledFlags(row, col)&flag != 0 {
+		return true
+	}
+	dir := r.arrowhead(row, col)
+	return dir != 0 && opposite(dir) == flag
+}
+
 func isLine(r rune, f int) bool {
 	props, ok := properties[r]
 	if !ok {
@@ -347,9 +685,17 @@ func checkMainLines(input *Region, row, col int) int {
 }
 
 // Process converts the input data to output string replacing ASCII
-// graphics with the corresponding ASCII Box-drawing characters.
+// graphics with the corresponding ASCII Box-drawing characters. The
+// minus signs (-) and vertical bars (|) are light lines, equal signs
+// (=) double lines, and number signs (#) heavy lines. The junctions
+// (+, *) are drawn with the styles of the lines they connect. The
+// arrowheads at the ends of lines are replaced with the corresponding
+// Unicode arrowhead characters. The runs of slashes and backslashes
+// are replaced with the diagonal line characters. The dashed lines
+// are replaced with the dashed line characters, see markDashed.
 func Process(data string) string {
 	input := NewRegion([]byte(data))
+	input.markDashed()
 	output := input.Clone()
 
 	for row := 0; row < input.Height(); row++ {
@@ -360,29 +706,55 @@ func Process(data string) string {
 				output.Set(row, col, 0x2502)
 			case '-':
 				output.Set(row, col, 0x2500)
-			case '+', '*', '\'', '\\', '/':
-				var index int
-				if isLine(input.Get(row-1, col), FlagDown) {
-					index |= FlagUp
+			case '>', '<', '^', 'v', 'V':
+				dir := input.arrowhead(row, col)
+				if dir != 0 {
+					output.Set(row, col, arrowheads[dir])
+				}
+			case '=', '#':
+				if l := input.styledLine(row, col); l != 0 {
+					output.Set(row, col, l)
+				}
+			case 'X', 'x':
+				if d := input.diagonal(row, col); d != 0 {
+					output.Set(row, col, d)
 				}
-				if isLine(input.Get(row+1, col), FlagUp) {
-					index |= FlagDown
+			case '+', '*', '\'', '\\', '/':
+				if d := input.diagonal(row, col); d != 0 {
+					output.Set(row, col, d)
+					break
 				}
-				if isLine(input.Get(row, col-1), FlagRight) {
-					index |= FlagLeft
+				var index int
+				var styles [4]lineStyle
+				for i, flag := range directions[:4] {
+					nr, nc := neighbor(row, col, flag)
+					if input.connects(nr, nc, opposite(flag)) {
+						index |= flag
+						styles[i] = asciiStyle(input.Get(nr, nc))
+					}
 				}
-				if isLine(input.Get(row, col+1), FlagLeft) {
-					index |= FlagRight
+				if index != 0 && (ch == '+' || ch == '*') {
+					joins := input.diagonalJoins(row, col) &^ index
+					for i, flag := range directions[:2] {
+						if joins&flag != 0 {
+							index |= flag
+							styles[i] = styleLight
+						}
+					}
 				}
 				if index == 0 {
 					output.Set(row, col, ch)
 				} else {
 					switch ch {
 					case '+', '\'':
-						output.Set(row, col, lineDrawing[index])
+						output.Set(row, col, junction(index, styles))
 
 					case '*':
-						output.Set(row, col, lineDrawingRound[index])
+						if styles == lightStyles(index) {
+							output.Set(row, col, lineDrawingRound[index])
+						} else {
+							output.Set(row, col, junction(index, styles))
+						}
 
 					case '\\':
 						mainLines := checkMainLines(input, row, col)
@@ -407,3 +779,25 @@ func Process(data string) string {
 
 	return output.String()
 }
+
+// Light corner runes and their rounded versions.
+var roundedCorners = map[rune]rune{
+	0x250C: 0x256D, // ┌ ╭
+	0x2510: 0x256E, // ┐ ╮
+	0x2514: 0x2570, // └ ╰
+	0x2518: 0x256F, // ┘ ╯
+}
+
+// Round converts the input data like Process but draws all light
+// corners rounded, as if they were drawn with asterisks (*).
+func Round(data string) string {
+	r := NewRegion([]byte(Process(data)))
+	for row := 0; row < r.Height(); row++ {
+		for col := 0; col < r.Width(); col++ {
+			if ch, ok := roundedCorners[r.Get(row, col)]; ok {
+				r.Set(row, col, ch)
+			}
+		}
+	}
+	return r.String()
+}
@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"strings"
+	"testing"
+)
+
+// processTest defines an ASCII art input and its expected output. The
+// leading and trailing newlines of the raw string literals are
+// ignored.
+type processTest struct {
+	input  string
+	output string
+}
+
+func testConvert(t *testing.T, name string, convert func(string) string,
+	tests []processTest) {
+
+	t.Helper()
+	for _, test := range tests {
+		input := strings.Trim(test.input, "\n")
+		expected := strings.Trim(test.output, "\n")
+		output := convert(input)
+		if output != expected {
+			t.Errorf("%s:\n%s\ngot:\n%s\nexpected:\n%s",
+				name, input, output, expected)
+		}
+	}
+}
+
+func TestProcessArrowheads(t *testing.T) {
+	testConvert(t, "Process", Process, []processTest{
+		{
+			input: `
++---+    ^
+|   |--> |
++---+    |
+  |      |
+  v   <--+`,
+			output: `
+┌───┐    ▲
+│   │──► │
+└───┘    │
+  │      │
+  ▼   ◄──┘`,
+		},
+		{
+			// Arrowheads must end a line.
+			input: `
+a > b
+x-v
+--<  >--`,
+			output: `
+a > b
+x─v
+──<  >──`,
+		},
+		{
+			// The vertical arrowheads are not taken from words.
+			input: `
+ |
+ v1`,
+			output: `
+ │
+ v1`,
+		},
+	})
+}
+
+func TestProcessDiagonals(t *testing.T) {
+	testConvert(t, "Process", Process, []processTest{
+		{
+			input: `
+\   /
+ \ /
+  X
+ / \`,
+			output: `
+╲   ╱
+ ╲ ╱
+  ╳
+ ╱ ╲`,
+		},
+		{
+			// The diagonals join the corners vertically.
+			input: `
+   +
+  / \
+ /   \
++-----+`,
+			output: `
+   +
+  ╱ ╲
+ ╱   ╲
+└─────┘`,
+		},
+		{
+			input: `
++--+
+|  |\
++--+ \
+      +--`,
+			output: `
+┌──┐
+│  │╲
+└──┘ ╲
+      └──`,
+		},
+		{
+			// Single slashes are text.
+			input:  `a/b and c\d`,
+			output: `a/b and c\d`,
+		},
+	})
+}
+
+func TestRound(t *testing.T) {
+	testConvert(t, "Round", Round, []processTest{
+		{
+			// Only the light corners are rounded.
+			input: `
++--+--+
+|  |  |
++--+--+
+
++==+
+|  |
++==+`,
+			output: `
+╭──┬──╮
+│  │  │
+╰──┴──╯
+
+╒══╕
+│  │
+╘══╛`,
+		},
+	})
+}
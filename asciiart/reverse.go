@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+// ASCII arrowheads by their directions.
+var asciiArrowheads = map[int]rune{
+	FlagUp:    '^',
+	FlagDown:  'v',
+	FlagLeft:  '<',
+	FlagRight: '>',
+}
+
+// Arrows used by other drawing tools by their directions.
+var foreignArrows = map[rune]int{
+	0x2190: FlagLeft,  // ←
+	0x2191: FlagUp,    // ↑
+	0x2192: FlagRight, // →
+	0x2193: FlagDown,  // ↓
+	0x25B3: FlagUp,    // △
+	0x25B4: FlagUp,    // ▴
+	0x25B6: FlagRight, // ▶
+	0x25B7: FlagRight, // ▷
+	0x25B8: FlagRight, // ▸
+	0x25BD: FlagDown,  // ▽
+	0x25BE: FlagDown,  // ▾
+	0x25C0: FlagLeft,  // ◀
+	0x25C1: FlagLeft,  // ◁
+	0x25C2: FlagLeft,  // ◂
+}
+
+// Reverse converts the box-drawing characters of the input data back
+// to ASCII graphics, see Process. The horizontal and vertical lines
+// are converted to minus signs (-) and vertical bars (|), or to equal
+// signs (=) and number signs (#) for the double and heavy lines. The
+// corners and junctions are converted to plus signs (+) and the
+// rounded corners to asterisks (*). The dashed lines are converted to
+// the dashed ASCII lines (- - -, ..., :). The arrows of other drawing
+// tools are converted to ASCII arrowheads if they end a line. All
+// other characters are kept as-is.
+//
+// Converting the result with Process gives back the input
+// diagram. Process and Reverse round-trip the ASCII diagrams which
+// draw their corners with plus signs and asterisks, their
+// arrowheads with <, >, ^, and v, and do not have plus signs inside
+// straight lines.
+func Reverse(data string) string {
+	input := NewRegion([]byte(data))
+	output := input.Clone()
+
+	for row := 0; row < input.Height(); row++ {
+		for col := 0; col < input.Width(); col++ {
+			ch := input.Get(row, col)
+
+			if dashStyles[ch] == styleDashed &&
+				boxFlags(ch) == FlagLeft|FlagRight {
+				end := col
+				for dashStyles[input.Get(row, end+1)] == styleDashed &&
+					boxFlags(input.Get(row, end+1)) == FlagLeft|FlagRight {
+					end++
+				}
+				// The dashes are at the even cells from the run
+				// start. The even length runs following left
+				// arrowheads start with the space before the first
+				// dash, see markDashed.
+				var start int
+				before := input.Get(row, col-1)
+				if (end-col)%2 == 1 && (arrowheadDirs[before] == FlagLeft ||
+					foreignArrows[before] == FlagLeft) {
+					start = 1
+				}
+				for i := col; i <= end; i++ {
+					if (i-col+start)%2 == 0 {
+						output.Set(row, i, '-')
+					} else {
+						output.Set(row, i, ' ')
+					}
+				}
+				col = end
+				continue
+			}
+			if dir, ok := foreignArrows[ch]; ok {
+				nr, nc := neighbor(row, col, opposite(dir))
+				if boxFlags(input.Get(nr, nc))&dir != 0 {
+					output.Set(row, col, asciiArrowheads[dir])
+				}
+				continue
+			}
+			if a := reverseRune(ch); a != 0 {
+				output.Set(row, col, a)
+			}
+		}
+	}
+	return output.String()
+}
+
+// reverseRune returns the ASCII character of the box-drawing or
+// arrowhead character ch. The function returns 0 if ch is not a
+// box-drawing or an arrowhead character.
+func reverseRune(ch rune) rune {
+	switch ch {
+	case 0x2571:
+		return '/'
+	case 0x2572:
+		return '\\'
+	case 0x2573:
+		return 'X'
+	}
+	if dir, ok := arrowheadDirs[ch]; ok {
+		return asciiArrowheads[dir]
+	}
+	if _, ok := dashStyles[ch]; ok {
+		if boxFlags(ch) == FlagUp|FlagDown {
+			return ':'
+		}
+		return '.'
+	}
+	flags := boxFlags(ch)
+	if flags == 0 {
+		return 0
+	}
+	if roundCorners[ch] {
+		return '*'
+	}
+	var line rune
+	switch flags {
+	case FlagLeft | FlagRight:
+		line = '-'
+	case FlagUp | FlagDown:
+		line = '|'
+	default:
+		return '+'
+	}
+	switch runeStyle(ch, flags&(FlagUp|FlagLeft)) {
+	case styleHeavy:
+		return '#'
+	case styleDouble:
+		return '='
+	default:
+		return line
+	}
+}
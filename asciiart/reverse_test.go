@@ -0,0 +1,81 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"testing"
+)
+
+func TestReverse(t *testing.T) {
+	testConvert(t, "Reverse", Reverse, []processTest{
+		{
+			input: `
+┌──┐
+│  ├──→
+└──┘
+  ↓ ←`,
+			output: `
++--+
+|  +-->
++--+
+  ↓ ←`,
+		},
+		{
+			input: `
+╔══╗ ┏━━┓ ╭──╮
+║  ║ ┃  ┃ ╰──╯
+╚══╝ ┗━━┛`,
+			output: `
++==+ +##+ *--*
+=  = #  # *--*
++==+ +##+`,
+		},
+		{
+			// The dashes are at the even cells from the run start.
+			input: `
+┌╌╌╌╌┐
+└┈┈┈┈┘`,
+			output: `
++- - +
++....+`,
+		},
+	})
+}
+
+func TestReverseRoundTrip(t *testing.T) {
+	tests := []string{
+		`
++--+--+    ^
+|  |  |--> |
++--+--+    |
+   |       |
+   v  <----*`,
+		`
++- - -+
+:     :
++.....+`,
+		`
+a - - > b
+c < - - d
+e <- - f
+g - - - h`,
+		`
++==+     +##+
+|  |===> #  #
++==+     +##+`,
+		`
+   +
+  / \
+ /   \
++-----+`,
+	}
+	for _, test := range tests {
+		testConvert(t, "Reverse(Process)", func(data string) string {
+			return Reverse(Process(data))
+		}, []processTest{{test, test}})
+	}
+}
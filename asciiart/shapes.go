@@ -0,0 +1,357 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Shape kinds.
+const (
+	ShapeBox       = "box"
+	ShapeConnector = "connector"
+)
+
+// The legend lines assign classes to the boxes with the label:
+//
+//	[Encrypt]: highlight crypto
+var (
+	reLegend = regexp.MustCompile(`^\s*\[([^\]]+)\]:\s*([\w\s-]+)$`)
+	reClass  = regexp.MustCompile(`^[A-Za-z_][\w-]*$`)
+)
+
+// Shape defines a shape recognized from an ASCII art diagram. The
+// Top, Left, Bottom, and Right define the shape's bounding rectangle
+// (inclusive). The Ends contain the boxes connected by a connector.
+type Shape struct {
+	Kind    string
+	Label   string
+	Classes []string
+	Top     int
+	Left    int
+	Bottom  int
+	Right   int
+	Ends    []*Shape
+}
+
+// Class returns the shape's class attribute value.
+func (s *Shape) Class() string {
+	return strings.Join(append([]string{s.Kind}, s.Classes...), " ")
+}
+
+type point struct {
+	row int
+	col int
+}
+
+// Diagram defines an ASCII art diagram and its shapes.
+type Diagram struct {
+	Region *Region
+	Shapes []*Shape
+	cells  map[point]*Shape
+}
+
+// NewDiagram parses the ASCII art diagram and recognizes its boxes,
+// their labels, and the connectors between the boxes. The legend
+// lines assign classes to the boxes with the given labels:
+//
+//	[Encrypt]: highlight crypto
+func NewDiagram(data string) *Diagram {
+	legend := make(map[string][]string)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(data, "\n"), "\n") {
+		m := reLegend.FindStringSubmatch(line)
+		if m == nil {
+			lines = append(lines, line)
+			continue
+		}
+		for _, class := range strings.Fields(m[2]) {
+			if reClass.MatchString(class) {
+				legend[m[1]] = append(legend[m[1]], class)
+			}
+		}
+	}
+
+	d := &Diagram{
+		Region: NewRegion([]byte(Process(strings.Join(lines, "\n")))),
+		cells:  make(map[point]*Shape),
+	}
+	d.findBoxes()
+	for _, s := range d.Shapes {
+		s.Classes = legend[s.Label]
+	}
+	d.findConnectors()
+
+	return d
+}
+
+// SVG renders the diagram as an SVG image.
+func (d *Diagram) SVG() string {
+	return d.Region.svg(d)
+}
+
+// HTML renders the diagram as HTML. The characters of the shapes are
+// wrapped in span elements having the shape classes.
+func (d *Diagram) HTML() string {
+	var b strings.Builder
+	r := d.Region
+
+	for row := 0; row < r.Height(); row++ {
+		if row > 0 {
+			b.WriteRune('\n')
+		}
+		var current *Shape
+		for col := range r.lines[row] {
+			s := d.shapeAt(row, col)
+			if s != current {
+				if current != nil {
+					b.WriteString("</span>")
+				}
+				if s != nil {
+					b.WriteString(`<span class="`)
+					b.WriteString(html.EscapeString(s.Class()))
+					b.WriteString(`">`)
+				}
+				current = s
+			}
+			b.WriteString(html.EscapeString(r.text(row, col)))
+		}
+		if current != nil {
+			b.WriteString("</span>")
+		}
+	}
+	return b.String()
+}
+
+// Summary returns a text summary of the diagram listing the labels of
+// its boxes. The function returns an empty string if the diagram does
+// not have labeled boxes.
+func (d *Diagram) Summary() string {
+	seen := make(map[string]bool)
+	var labels []string
+	for _, s := range d.Shapes {
+		if s.Kind != ShapeBox || len(s.Label) == 0 || seen[s.Label] {
+			continue
+		}
+		seen[s.Label] = true
+		labels = append(labels, s.Label)
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	return "Diagram: " + strings.Join(labels, ", ")
+}
+
+// shapeAt returns the shape of the cell or nil if the cell does not
+// belong to any shape.
+func (d *Diagram) shapeAt(row, col int) *Shape {
+	if d == nil {
+		return nil
+	}
+	return d.cells[point{row, col}]
+}
+
+func (d *Diagram) findBoxes() {
+	r := d.Region
+	var boxes []*Shape
+
+	for row := 0; row < r.Height(); row++ {
+		for col := 0; col < r.Width(); col++ {
+			if boxFlags(r.Get(row, col))&(FlagDown|FlagRight) !=
+				FlagDown|FlagRight {
+				continue
+			}
+			for x := col + 1; ; x++ {
+				flags := boxFlags(r.Get(row, x))
+				if flags&FlagLeft == 0 {
+					break
+				}
+				if flags&FlagDown != 0 {
+					y, ok := r.closeBox(row, col, x)
+					if ok {
+						boxes = append(boxes, &Shape{
+							Kind:   ShapeBox,
+							Label:  r.label(row, col, y, x),
+							Top:    row,
+							Left:   col,
+							Bottom: y,
+							Right:  x,
+						})
+						break
+					}
+				}
+				if flags&FlagRight == 0 {
+					break
+				}
+			}
+		}
+	}
+
+	// Assign cells from the largest boxes to the smallest so that the
+	// nested boxes get their own cells.
+	sorted := make([]*Shape, len(boxes))
+	copy(sorted, boxes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return area(sorted[i]) > area(sorted[j])
+	})
+	for _, box := range sorted {
+		for row := box.Top; row <= box.Bottom; row++ {
+			for col := box.Left; col <= box.Right; col++ {
+				d.cells[point{row, col}] = box
+			}
+		}
+	}
+	d.Shapes = append(d.Shapes, boxes...)
+}
+
+func area(s *Shape) int {
+	return (s.Bottom - s.Top + 1) * (s.Right - s.Left + 1)
+}
+
+// closeBox finds the bottom row of the box having the top-left corner
+// at top, left and the top-right corner at top, right. The function
+// returns the bottom row and a boolean indicating if the box was
+// closed.
+func (r *Region) closeBox(top, left, right int) (int, bool) {
+	for y := top + 1; ; y++ {
+		lf := boxFlags(r.Get(y, left))
+		rf := boxFlags(r.Get(y, right))
+		if lf&FlagUp == 0 || rf&FlagUp == 0 {
+			return 0, false
+		}
+		if lf&FlagRight != 0 && rf&FlagLeft != 0 {
+			closed := true
+			for x := left + 1; x < right; x++ {
+				if boxFlags(r.Get(y, x))&(FlagLeft|FlagRight) !=
+					FlagLeft|FlagRight {
+					closed = false
+					break
+				}
+			}
+			if closed {
+				return y, true
+			}
+		}
+		if lf&FlagDown == 0 || rf&FlagDown == 0 {
+			return 0, false
+		}
+	}
+}
+
+// label returns the text inside the box. The rows of the text are
+// joined with spaces.
+func (r *Region) label(top, left, bottom, right int) string {
+	var parts []string
+	for row := top + 1; row < bottom; row++ {
+		var line strings.Builder
+		for col := left + 1; col < right; col++ {
+			ch := r.Get(row, col)
+			if boxFlags(ch) != 0 || arrowheadDirs[ch] != 0 {
+				line.WriteRune(' ')
+			} else {
+				line.WriteString(r.text(row, col))
+			}
+		}
+		text := strings.Join(strings.Fields(line.String()), " ")
+		if len(text) > 0 {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// findConnectors finds the connected lines outside the boxes. The
+// connectors connecting to boxes are recorded with their end boxes.
+func (d *Diagram) findConnectors() {
+	r := d.Region
+	seen := make(map[point]bool)
+
+	isBorder := func(p point) bool {
+		s := d.cells[p]
+		if s == nil || s.Kind != ShapeBox {
+			return false
+		}
+		return p.row == s.Top || p.row == s.Bottom ||
+			p.col == s.Left || p.col == s.Right
+	}
+	// links returns the connection directions of the cell.
+	links := func(p point) int {
+		ch := r.Get(p.row, p.col)
+		dir := arrowheadDirs[ch]
+		if dir != 0 {
+			return dir | opposite(dir)
+		}
+		return boxFlags(ch)
+	}
+
+	for row := 0; row < r.Height(); row++ {
+		for col := 0; col < r.Width(); col++ {
+			start := point{row, col}
+			if seen[start] || links(start) == 0 || isBorder(start) {
+				continue
+			}
+			connector := &Shape{
+				Kind:   ShapeConnector,
+				Top:    row,
+				Left:   col,
+				Bottom: row,
+				Right:  col,
+			}
+			ends := make(map[*Shape]bool)
+			stack := []point{start}
+			seen[start] = true
+
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				d.cells[p] = connector
+				connector.extend(p)
+
+				flags := links(p)
+				for _, dir := range directions {
+					if flags&dir == 0 {
+						continue
+					}
+					nr, nc := neighbor(p.row, p.col, dir)
+					n := point{nr, nc}
+					if isBorder(n) {
+						box := d.cells[n]
+						if !ends[box] {
+							ends[box] = true
+							connector.Ends = append(connector.Ends, box)
+						}
+						continue
+					}
+					if seen[n] || links(n)&opposite(dir) == 0 {
+						continue
+					}
+					seen[n] = true
+					stack = append(stack, n)
+				}
+			}
+			d.Shapes = append(d.Shapes, connector)
+		}
+	}
+}
+
+func (s *Shape) extend(p point) {
+	if p.row < s.Top {
+		s.Top = p.row
+	}
+	if p.row > s.Bottom {
+		s.Bottom = p.row
+	}
+	if p.col < s.Left {
+		s.Left = p.col
+	}
+	if p.col > s.Right {
+		s.Right = p.col
+	}
+}
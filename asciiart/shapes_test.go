@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"strings"
+	"testing"
+)
+
+var shapesInput = `
++-----+     +-----+
+| Foo |---->| Bar |
++-----+     +-----+
+[Foo]: highlight`
+
+func TestDiagramShapes(t *testing.T) {
+	d := NewDiagram(strings.Trim(shapesInput, "\n"))
+
+	if len(d.Shapes) != 3 {
+		t.Fatalf("got %d shapes, expected 3", len(d.Shapes))
+	}
+	expected := []struct {
+		class  string
+		label  string
+		top    int
+		left   int
+		bottom int
+		right  int
+	}{
+		{"box highlight", "Foo", 0, 0, 2, 6},
+		{"box", "Bar", 0, 12, 2, 18},
+		{"connector", "", 1, 7, 1, 11},
+	}
+	for i, e := range expected {
+		s := d.Shapes[i]
+		if s.Class() != e.class || s.Label != e.label || s.Top != e.top ||
+			s.Left != e.left || s.Bottom != e.bottom || s.Right != e.right {
+			t.Errorf("shape %d: got %q %q %d,%d-%d,%d, expected %q %q %d,%d-%d,%d",
+				i, s.Class(), s.Label, s.Top, s.Left, s.Bottom, s.Right,
+				e.class, e.label, e.top, e.left, e.bottom, e.right)
+		}
+	}
+	ends := d.Shapes[2].Ends
+	if len(ends) != 2 || ends[0] != d.Shapes[0] || ends[1] != d.Shapes[1] {
+		t.Errorf("connector ends: got %v, expected Foo and Bar", ends)
+	}
+}
+
+func TestDiagramHTML(t *testing.T) {
+	html := NewDiagram(strings.Trim(shapesInput, "\n")).HTML()
+	expected := strings.Trim(`
+<span class="box highlight">┌─────┐</span>     <span class="box">┌─────┐</span>
+<span class="box highlight">│ Foo │</span><span class="connector">────►</span><span class="box">│ Bar │</span>
+<span class="box highlight">└─────┘</span>     <span class="box">└─────┘</span>`, "\n")
+	if html != expected {
+		t.Errorf("HTML:\n%s\nexpected:\n%s", html, expected)
+	}
+}
+
+func TestDiagramSummary(t *testing.T) {
+	tests := []struct {
+		input   string
+		summary string
+	}{
+		{
+			input:   shapesInput,
+			summary: "Diagram: Foo, Bar",
+		},
+		{
+			input: `
++-----+  +-----+
+| Foo |  | Foo |
++-----+  +-----+
++---+
+|   |
++---+`,
+			summary: "Diagram: Foo",
+		},
+		{
+			input:   "--> a",
+			summary: "",
+		},
+	}
+	for _, test := range tests {
+		summary := NewDiagram(strings.Trim(test.input, "\n")).Summary()
+		if summary != test.summary {
+			t.Errorf("Summary: got %q, expected %q", summary, test.summary)
+		}
+	}
+}
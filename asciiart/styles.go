@@ -0,0 +1,125 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+// lineStyle defines box-drawing line styles.
+type lineStyle int
+
+// Line styles.
+const (
+	styleNone lineStyle = iota
+	styleLight
+	styleHeavy
+	styleDouble
+	styleDashed
+	styleDotted
+)
+
+// lineStyles defines the line styles of the box-drawing characters in
+// the up, down, left, right order. The styles are derived from the
+// line style flags of the properties. The dashed lines, arcs, and
+// diagonals are not included since they are not used as junctions.
+var lineStyles = make(map[rune][4]lineStyle)
+
+// styledRunes maps the line styles to the box-drawing characters.
+var styledRunes = make(map[[4]lineStyle]rune)
+
+func init() {
+	for r, props := range properties {
+		if r < 0x2500 || props&diagonals != 0 || roundCorners[r] {
+			continue
+		}
+		if _, ok := dashStyles[r]; ok {
+			continue
+		}
+		var styles [4]lineStyle
+		for i, flag := range directions[:4] {
+			switch {
+			case props&(flag<<heavyShift) != 0:
+				styles[i] = styleHeavy
+			case props&(flag<<doubleShift) != 0:
+				styles[i] = styleDouble
+			case props&flag != 0:
+				styles[i] = styleLight
+			}
+		}
+		lineStyles[r] = styles
+		styledRunes[styles] = r
+	}
+}
+
+// lightStyles returns the light line styles for the connection
+// directions index.
+func lightStyles(index int) [4]lineStyle {
+	var styles [4]lineStyle
+	for i := range styles {
+		if index&(1<<i) != 0 {
+			styles[i] = styleLight
+		}
+	}
+	return styles
+}
+
+// asciiStyle returns the line style of the ASCII character ch.
+func asciiStyle(ch rune) lineStyle {
+	switch ch {
+	case '=':
+		return styleDouble
+	case '#':
+		return styleHeavy
+	default:
+		return styleLight
+	}
+}
+
+// runeStyle returns the line style of the box-drawing character ch to
+// the direction flag.
+func runeStyle(ch rune, flag int) lineStyle {
+	style, ok := dashStyles[ch]
+	if ok {
+		return style
+	}
+	styles, ok := lineStyles[ch]
+	if !ok {
+		return styleLight
+	}
+	switch flag {
+	case FlagUp:
+		return styles[0]
+	case FlagDown:
+		return styles[1]
+	case FlagLeft:
+		return styles[2]
+	case FlagRight:
+		return styles[3]
+	default:
+		return styleLight
+	}
+}
+
+// junction returns the box-drawing character for the connection
+// directions index and their line styles. If the styles do not have a
+// box-drawing character, the heavy and then the double lines are
+// replaced with the light lines.
+func junction(index int, styles [4]lineStyle) rune {
+	r, ok := styledRunes[styles]
+	if ok {
+		return r
+	}
+	for _, from := range []lineStyle{styleHeavy, styleDouble} {
+		for i := range styles {
+			if styles[i] == from {
+				styles[i] = styleLight
+			}
+		}
+		r, ok = styledRunes[styles]
+		if ok {
+			return r
+		}
+	}
+	return lineDrawing[index]
+}
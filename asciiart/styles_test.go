@@ -0,0 +1,109 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"testing"
+)
+
+func TestProcessStyles(t *testing.T) {
+	testConvert(t, "Process", Process, []processTest{
+		{
+			input: `
++====+
+#    #
++====+`,
+			output: `
+╒════╕
+┃    ┃
+╘════╛`,
+		},
+		{
+			input: `
++####+
+|    |
++----+`,
+			output: `
+┍━━━━┑
+│    │
+└────┘`,
+		},
+		{
+			input: `
+a ==> b <== c
++--+
+|  |==>`,
+			output: `
+a ══► b ◄══ c
+┌──┐
+│  │══►`,
+		},
+		{
+			// The vertical lines end at corners.
+			input: `
++==+
+|  |
++--+
+ #
+ #
+ +`,
+			output: `
+╒══╕
+│  │
+└──┘
+ ┃
+ ┃
+ ╹`,
+		},
+	})
+}
+
+func TestProcessStyleLabels(t *testing.T) {
+	// The equal and number signs of text labels are not lines.
+	testConvert(t, "Process", Process, []processTest{
+		{
+			input: `
+| key=value |
+| max=10    |`,
+			output: `
+│ key=value │
+│ max=10    │`,
+		},
+		{
+			input: `
+issue #12
+issue #13`,
+			output: `
+issue #12
+issue #13`,
+		},
+		{
+			input:  `a == b`,
+			output: `a == b`,
+		},
+		{
+			input: `
++--+ x==
+|  |==y
++--+`,
+			output: `
+┌──┐ x==
+│  │==y
+└──┘`,
+		},
+		{
+			input: `
+######
+#    #
+######`,
+			output: `
+######
+#    #
+######`,
+		},
+	})
+}
@@ -0,0 +1,302 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// SVG cell dimensions and the text font size.
+const (
+	CellWidth  = 10
+	CellHeight = 20
+	FontSize   = 16
+)
+
+// Rounded corner runes.
+var roundCorners = map[rune]bool{
+	0x256D: true,
+	0x256E: true,
+	0x256F: true,
+	0x2570: true,
+}
+
+// SVG converts the ASCII art data into an SVG image. The lines,
+// corners, and arrowheads are rendered as vector paths and all other
+// characters as selectable text. The recognized shapes are rendered
+// as groups, see NewDiagram.
+func SVG(data string) string {
+	return NewDiagram(data).SVG()
+}
+
+// SVG renders the region as an SVG image. The region must contain
+// box-drawing characters, see Process.
+func (r *Region) SVG() string {
+	return r.svg(nil)
+}
+
+// svgGroup collects the SVG elements of a shape.
+type svgGroup struct {
+	lines  [6]strings.Builder
+	arrows strings.Builder
+	text   strings.Builder
+}
+
+// svg renders the region as an SVG image. The elements of the
+// diagram's shapes are rendered in groups having the shape classes.
+func (r *Region) svg(d *Diagram) string {
+	groups := make(map[*Shape]*svgGroup)
+	group := func(row, col int) *svgGroup {
+		s := d.shapeAt(row, col)
+		g, ok := groups[s]
+		if !ok {
+			g = new(svgGroup)
+			groups[s] = g
+		}
+		return g
+	}
+
+	for row := 0; row < r.Height(); row++ {
+		var run []string
+		var start int
+		var runGroup *svgGroup
+
+		flush := func() {
+			for len(run) > 0 && run[len(run)-1] == " " {
+				run = run[:len(run)-1]
+			}
+			if len(run) == 0 {
+				return
+			}
+			s := strings.Join(run, "")
+			fmt.Fprintf(&runGroup.text,
+				`<text x="%d" y="%d" textLength="%d" lengthAdjust="spacingAndGlyphs">%s</text>`+"\n",
+				start*CellWidth, row*CellHeight+CellHeight*3/4,
+				len(run)*CellWidth, html.EscapeString(s))
+			run = nil
+		}
+
+		for col := 0; col < r.Width(); col++ {
+			if r.continuation(row, col) {
+				// The second cell of a wide character extends the
+				// text run.
+				if len(run) > 0 {
+					run = append(run, "")
+				}
+				continue
+			}
+			ch := r.Get(row, col)
+			flags := r.svgFlags(row, col)
+			dir := arrowheadDirs[ch]
+			g := group(row, col)
+
+			switch {
+			case flags != 0:
+				flush()
+				svgLine(&g.lines, row, col, ch, flags)
+
+			case dir != 0:
+				flush()
+				svgArrowhead(&g.lines[styleLight], &g.arrows, row, col, dir)
+
+			case ch == 0 || ch == ' ':
+				if ch == 0 || r.Get(row, col+1) == ' ' || g != runGroup {
+					flush()
+				} else if len(run) > 0 {
+					run = append(run, " ")
+				}
+
+			default:
+				if g != runGroup {
+					flush()
+				}
+				if len(run) == 0 {
+					start = col
+					runGroup = g
+				}
+				run = append(run, r.text(row, col))
+			}
+		}
+		flush()
+	}
+
+	width := r.Width() * CellWidth
+	height := r.Height() * CellHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b,
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" fill="currentColor" font-family="menlo, consolas, monospace" font-size="%d">`+"\n",
+		width, height, width, height, FontSize)
+
+	if g, ok := groups[nil]; ok {
+		g.write(&b)
+	}
+	if d != nil {
+		for _, s := range d.Shapes {
+			g, ok := groups[s]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, `<g class="%s"`, html.EscapeString(s.Class()))
+			if len(s.Label) > 0 {
+				fmt.Fprintf(&b, ` data-label="%s"`, html.EscapeString(s.Label))
+			}
+			b.WriteString(">\n")
+			g.write(&b)
+			b.WriteString("</g>\n")
+		}
+	}
+	b.WriteString("</svg>")
+
+	return b.String()
+}
+
+// write writes the group elements to the builder.
+func (g *svgGroup) write(b *strings.Builder) {
+	// The dash patterns divide the half cells evenly so that the
+	// patterns continue over the cells.
+	attrs := []string{
+		styleLight:  `stroke-width="1.5"`,
+		styleHeavy:  `stroke-width="3"`,
+		styleDouble: `stroke-width="1"`,
+		styleDashed: `stroke-width="1.5" stroke-dasharray="3 2"`,
+		styleDotted: `stroke-width="1.5" stroke-dasharray="0.5 2"`,
+	}
+	for style, attr := range attrs {
+		if g.lines[style].Len() == 0 {
+			continue
+		}
+		fmt.Fprintf(b,
+			`<path d="%s" fill="none" stroke="currentColor" %s stroke-linecap="round"/>`+"\n",
+			strings.TrimSpace(g.lines[style].String()), attr)
+	}
+	if g.arrows.Len() > 0 {
+		fmt.Fprintf(b, `<path d="%s"/>`+"\n", strings.TrimSpace(g.arrows.String()))
+	}
+	b.WriteString(g.text.String())
+}
+
+// cellCenter returns the center point of the cell.
+func cellCenter(row, col int) (int, int) {
+	return col*CellWidth + CellWidth/2, row*CellHeight + CellHeight/2
+}
+
+// cellEdge returns the point of the cell edge in the direction
+// flag. The diagonal directions return the corners of the cell.
+func cellEdge(row, col, flag int) (int, int) {
+	x, y := cellCenter(row, col)
+	dr, dc := neighbor(0, 0, flag)
+	return x + dc*CellWidth/2, y + dr*CellHeight/2
+}
+
+// boxFlags returns the connection directions of the box-drawing
+// character ch.
+func boxFlags(ch rune) int {
+	if ch < 0x2500 {
+		return 0
+	}
+	return properties[ch] & directionFlags
+}
+
+// svgFlags returns the line directions of the cell. The lines
+// include the joins to the diagonal lines ending at the cell. The
+// diagonal lines also join the lines ending at them. The unconnected
+// plus signs and asterisks join the diagonal lines ending at them. The
+// vertical stubs of the corners joining diagonal lines are removed,
+// see Process.
+func (r *Region) svgFlags(row, col int) int {
+	ch := r.Get(row, col)
+	flags := boxFlags(ch)
+	if flags == 0 && ch != '+' && ch != '*' {
+		return 0
+	}
+	for _, dir := range directions {
+		if dir&diagonals == 0 && flags&diagonals == 0 {
+			continue
+		}
+		nr, nc := neighbor(row, col, dir)
+		if boxFlags(r.Get(nr, nc))&opposite(dir) != 0 {
+			flags |= dir
+		}
+	}
+	for dir, side := range map[int]int{
+		FlagUp:   FlagUpLeft | FlagUpRight,
+		FlagDown: FlagDownLeft | FlagDownRight,
+	} {
+		if flags&dir == 0 || flags&side == 0 {
+			continue
+		}
+		nr, nc := neighbor(row, col, dir)
+		ch := r.Get(nr, nc)
+		if boxFlags(ch)&opposite(dir) == 0 && arrowheadDirs[ch] != dir {
+			flags &^= dir
+		}
+	}
+	return flags
+}
+
+// svgLine draws the line segments of the box-drawing character ch
+// from the cell center towards the directions of the flags. The
+// segments are drawn into the path data of their line styles.
+func svgLine(lines *[6]strings.Builder, row, col int, ch rune, flags int) {
+	cx, cy := cellCenter(row, col)
+
+	if roundCorners[ch] && flags == boxFlags(ch) {
+		var ends []int
+		for _, flag := range directions {
+			if flags&flag != 0 {
+				ends = append(ends, flag)
+			}
+		}
+		x0, y0 := cellEdge(row, col, ends[0])
+		x1, y1 := cellEdge(row, col, ends[1])
+		fmt.Fprintf(&lines[styleLight], "M%d %d Q%d %d %d %d ",
+			x0, y0, cx, cy, x1, y1)
+		return
+	}
+	for _, flag := range directions {
+		if flags&flag == 0 {
+			continue
+		}
+		x, y := cellEdge(row, col, flag)
+		style := runeStyle(ch, flag)
+		if style != styleDouble {
+			fmt.Fprintf(&lines[style], "M%d %d L%d %d ", cx, cy, x, y)
+			continue
+		}
+		// Double lines are two parallel lines which start before
+		// the cell center so that the corners join.
+		dy, dx := neighbor(0, 0, flag)
+		for _, o := range []int{-2, 2} {
+			fmt.Fprintf(&lines[style], "M%d %d L%d %d ",
+				cx-2*dx+o*dy, cy-2*dy+o*dx, x+o*dy, y+o*dx)
+		}
+	}
+}
+
+// svgArrowhead draws the arrowhead pointing to the direction dir. The
+// vertical arrowheads are half cell high so their shafts continue to
+// the lines above or below them.
+func svgArrowhead(lines, arrows *strings.Builder, row, col, dir int) {
+	cx, cy := cellCenter(row, col)
+	tx, ty := cellEdge(row, col, dir)
+
+	switch dir {
+	case FlagLeft, FlagRight:
+		bx := 2*cx - tx
+		fmt.Fprintf(arrows, "M%d %d L%d %d L%d %d Z ",
+			bx, cy-4, tx, ty, bx, cy+4)
+
+	case FlagUp, FlagDown:
+		fmt.Fprintf(arrows, "M%d %d L%d %d L%d %d Z ",
+			cx-4, cy, tx, ty, cx+4, cy)
+		sx, sy := cellEdge(row, col, (FlagUp|FlagDown)&^dir)
+		fmt.Fprintf(lines, "M%d %d L%d %d ", cx, cy, sx, sy)
+	}
+}
@@ -0,0 +1,52 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSVG(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect []string
+		absent []string
+	}{
+		{
+			input: "a<b & c",
+			expect: []string{
+				`viewBox="0 0 70 20" width="70" height="20"`,
+				`<text x="0" y="15" textLength="70" lengthAdjust="spacingAndGlyphs">a&lt;b &amp; c</text>`,
+			},
+		},
+		{
+			input: "+-+\n| |\n+-+",
+			expect: []string{
+				`viewBox="0 0 30 60" width="30" height="60"`,
+				`<path d="M5 10 L5 20 M5 10 L10 10 `,
+				`stroke-width="1.5"`,
+			},
+			absent: []string{
+				"<text",
+			},
+		},
+	}
+	for _, test := range tests {
+		svg := SVG(test.input)
+		for _, e := range test.expect {
+			if !strings.Contains(svg, e) {
+				t.Errorf("SVG(%q): %q not in output:\n%s", test.input, e, svg)
+			}
+		}
+		for _, a := range test.absent {
+			if strings.Contains(svg, a) {
+				t.Errorf("SVG(%q): %q in output:\n%s", test.input, a, svg)
+			}
+		}
+	}
+}
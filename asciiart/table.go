@@ -0,0 +1,262 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// Table defines a grid table drawn with ASCII characters. The Columns
+// contain the widths of the grid columns in characters. The rows
+// above the header separator line (+===+) are header rows.
+//
+//	+-------+-------+
+//	| Name  | Value |
+//	+=======+=======+
+//	| a     | 1     |
+//	+-------+       +
+//	| b     |       |
+//	+-------+-------+
+type Table struct {
+	Columns    []int
+	Rows       int
+	HeaderRows int
+	Cells      []*TableCell
+	grid       [][]*TableCell
+}
+
+// TableCell defines a table cell. The Row and Col specify the grid
+// position of the cell's top-left corner and the RowSpan and ColSpan
+// the number of grid rows and columns the cell covers.
+type TableCell struct {
+	Text    string
+	Row     int
+	Col     int
+	RowSpan int
+	ColSpan int
+}
+
+// Header tests if the cell is a header cell.
+func (t *Table) Header(c *TableCell) bool {
+	return c.Row < t.HeaderRows
+}
+
+// CellAt returns the cell covering the grid position row, col.
+func (t *Table) CellAt(row, col int) *TableCell {
+	if row < 0 || row >= len(t.grid) || col < 0 || col >= len(t.grid[row]) {
+		return nil
+	}
+	return t.grid[row][col]
+}
+
+// ParseTable parses the grid table from the data. The cells are
+// separated by the minus signs (-), vertical bars (|), and plus signs
+// (+) at the cell corners. The merged cells span several grid rows or
+// columns. The function returns an error if the data is not a grid
+// table.
+func ParseTable(data string) (*Table, error) {
+	r := NewRegion([]byte(strings.Trim(data, "\n")))
+	if r.Get(0, 0) != '+' || !strings.ContainsRune("-=", r.Get(0, 1)) {
+		return nil, errNotTable
+	}
+
+	// The grid lines are at the rows and columns having corners.
+	rowSet := make(map[int]bool)
+	colSet := make(map[int]bool)
+	for row := 0; row < r.Height(); row++ {
+		for col := 0; col < r.Width(); col++ {
+			if r.tableCorner(row, col) {
+				rowSet[row] = true
+				colSet[col] = true
+			}
+		}
+	}
+	rows := sortedKeys(rowSet)
+	cols := sortedKeys(colSet)
+	if len(rows) < 2 || len(cols) < 2 || rows[0] != 0 || cols[0] != 0 {
+		return nil, errNotTable
+	}
+
+	t := &Table{
+		Rows: len(rows) - 1,
+	}
+	for i := 1; i < len(cols); i++ {
+		t.Columns = append(t.Columns, cols[i]-cols[i-1]-1)
+	}
+	for i := 1; i < len(rows)-1; i++ {
+		if r.Get(rows[i], 1) == '=' {
+			t.HeaderRows = i
+			break
+		}
+	}
+	t.grid = make([][]*TableCell, t.Rows)
+	for i := range t.grid {
+		t.grid[i] = make([]*TableCell, len(t.Columns))
+	}
+
+	for ri := 0; ri < t.Rows; ri++ {
+		for ci := 0; ci < len(t.Columns); ci++ {
+			if t.grid[ri][ci] != nil {
+				continue
+			}
+			cell := r.tableCell(rows, cols, ri, ci)
+			if cell == nil {
+				return nil, fmt.Errorf("invalid table cell at line %d, column %d",
+					rows[ri]+1, cols[ci]+1)
+			}
+			for y := cell.Row; y < cell.Row+cell.RowSpan; y++ {
+				for x := cell.Col; x < cell.Col+cell.ColSpan; x++ {
+					if t.grid[y][x] != nil {
+						return nil, fmt.Errorf(
+							"overlapping table cells at line %d, column %d",
+							rows[y]+1, cols[x]+1)
+					}
+					t.grid[y][x] = cell
+				}
+			}
+			t.Cells = append(t.Cells, cell)
+		}
+	}
+	return t, nil
+}
+
+var errNotTable = errors.New("not a grid table")
+
+func sortedKeys(m map[int]bool) []int {
+	var keys []int
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// tableCorner tests if the cell is a table corner: a plus sign
+// connected to a horizontal or vertical edge. The plus signs of the
+// cell texts are not corners.
+func (r *Region) tableCorner(row, col int) bool {
+	if r.Get(row, col) != '+' {
+		return false
+	}
+	return strings.ContainsRune("-=", r.Get(row, col-1)) ||
+		strings.ContainsRune("-=", r.Get(row, col+1)) ||
+		r.Get(row-1, col) == '|' || r.Get(row+1, col) == '|'
+}
+
+// tableCell finds the smallest cell having its top-left corner at the
+// grid position ri, ci. The rows and cols contain the region
+// coordinates of the grid lines. The function returns nil if the cell
+// is not closed.
+func (r *Region) tableCell(rows, cols []int, ri, ci int) *TableCell {
+	top := rows[ri]
+	left := cols[ci]
+	if r.Get(top, left) != '+' {
+		return nil
+	}
+	for cj := ci + 1; cj < len(cols); cj++ {
+		right := cols[cj]
+		if !r.tableEdge(top, left, 0, 1, right-left, "-=+") {
+			return nil
+		}
+		if r.Get(top, right) != '+' {
+			continue
+		}
+		for rk := ri + 1; rk < len(rows); rk++ {
+			bottom := rows[rk]
+			if !r.tableEdge(top, left, 1, 0, bottom-top, "|+") {
+				break
+			}
+			if r.Get(bottom, left) != '+' || r.Get(bottom, right) != '+' ||
+				!r.tableEdge(top, right, 1, 0, bottom-top, "|+") ||
+				!r.tableEdge(bottom, left, 0, 1, right-left, "-=+") {
+				continue
+			}
+			return &TableCell{
+				Text:    r.tableText(top, left, bottom, right),
+				Row:     ri,
+				Col:     ci,
+				RowSpan: rk - ri,
+				ColSpan: cj - ci,
+			}
+		}
+	}
+	return nil
+}
+
+// tableEdge tests if the count cells starting from row, col are cell
+// edge characters. The dr and dc specify the edge direction.
+func (r *Region) tableEdge(row, col, dr, dc, count int, chars string) bool {
+	for i := 0; i <= count; i++ {
+		if !strings.ContainsRune(chars, r.Get(row+i*dr, col+i*dc)) {
+			return false
+		}
+	}
+	return true
+}
+
+// tableText returns the text inside the cell. The lines of the text
+// are joined with spaces.
+func (r *Region) tableText(top, left, bottom, right int) string {
+	var parts []string
+	for row := top + 1; row < bottom; row++ {
+		var line strings.Builder
+		for col := left + 1; col < right; col++ {
+			line.WriteString(r.text(row, col))
+		}
+		text := strings.TrimSpace(line.String())
+		if len(text) > 0 {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// HTML renders the table as an HTML table.
+func (t *Table) HTML() string {
+	var b strings.Builder
+
+	b.WriteString("<table>\n")
+	for row := 0; row < t.Rows; row++ {
+		if row == 0 && t.HeaderRows > 0 {
+			b.WriteString("<thead>\n")
+		} else if row == t.HeaderRows {
+			b.WriteString("<tbody>\n")
+		}
+		b.WriteString("<tr>")
+		for _, c := range t.Cells {
+			if c.Row != row {
+				continue
+			}
+			tag := "td"
+			if t.Header(c) {
+				tag = "th"
+			}
+			b.WriteString("<" + tag)
+			if c.RowSpan > 1 {
+				fmt.Fprintf(&b, ` rowspan="%d"`, c.RowSpan)
+			}
+			if c.ColSpan > 1 {
+				fmt.Fprintf(&b, ` colspan="%d"`, c.ColSpan)
+			}
+			fmt.Fprintf(&b, ">%s</%s>", html.EscapeString(c.Text), tag)
+		}
+		b.WriteString("</tr>\n")
+		if row+1 == t.HeaderRows {
+			b.WriteString("</thead>\n")
+		}
+	}
+	if t.Rows > t.HeaderRows {
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>")
+
+	return b.String()
+}
@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTable(t *testing.T) {
+	table, err := ParseTable(`
++-------+-------+
+| Name  | Value |
++=======+=======+
+| a     | 1     |
++-------+       +
+| b c   |       |
+| d     |       |
++-------+-------+
+`)
+	if err != nil {
+		t.Fatalf("ParseTable: %s", err)
+	}
+	if table.Rows != 3 || table.HeaderRows != 1 ||
+		len(table.Columns) != 2 || table.Columns[0] != 7 ||
+		table.Columns[1] != 7 {
+		t.Errorf("got %d rows, %d header rows, columns %v",
+			table.Rows, table.HeaderRows, table.Columns)
+	}
+	expected := strings.Trim(`
+<table>
+<thead>
+<tr><th>Name</th><th>Value</th></tr>
+</thead>
+<tbody>
+<tr><td>a</td><td rowspan="2">1</td></tr>
+<tr><td>b c d</td></tr>
+</tbody>
+</table>`, "\n")
+	if html := table.HTML(); html != expected {
+		t.Errorf("HTML:\n%s\nexpected:\n%s", html, expected)
+	}
+}
+
+func TestParseTableSpans(t *testing.T) {
+	table, err := ParseTable(`
++---+---+---+
+| a         |
++---+---+---+
+| b | c | d |
++---+---+---+`)
+	if err != nil {
+		t.Fatalf("ParseTable: %s", err)
+	}
+	c := table.CellAt(0, 2)
+	if c == nil || c.Text != "a" || c.ColSpan != 3 || table.Header(c) {
+		t.Errorf("CellAt(0, 2): got %+v", c)
+	}
+	c = table.CellAt(1, 2)
+	if c == nil || c.Text != "d" || c.ColSpan != 1 {
+		t.Errorf("CellAt(1, 2): got %+v", c)
+	}
+}
+
+func TestParseTableErrors(t *testing.T) {
+	tests := []string{
+		"a plain text",
+		`
++---+     +---+
+| a |---->| b |
++---+     +---+`,
+		`
++---+---+
+| a | b
++---+---+`,
+	}
+	for _, test := range tests {
+		_, err := ParseTable(test)
+		if err == nil {
+			t.Errorf("ParseTable(%q): expected an error", test)
+		}
+	}
+}
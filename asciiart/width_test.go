@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package asciiart
+
+import (
+	"testing"
+)
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+	}{
+		{"abc", 3},
+		{"日本", 4},
+		{"cafe\u0301", 4},
+		{"a\u200db", 2},
+		{"🚀", 2},
+		{"👩\u200d💻", 2},
+		{"👨\u200d👩\u200d👧", 2},
+		{"🏳\ufe0f\u200d🌈", 2},
+		{"👍🏽", 2},
+		{"👍🏽👍", 4},
+		{"🏃\u200d\u2640\ufe0f", 2},
+		{"🏽", 2},
+		{"\u2764\ufe0f", 1},
+		{"", 0},
+	}
+	for _, test := range tests {
+		width := StringWidth(test.s)
+		if width != test.width {
+			t.Errorf("StringWidth(%q)=%d, expected %d", test.s, width,
+				test.width)
+		}
+	}
+}
+
+func TestProcessWide(t *testing.T) {
+	testConvert(t, "Process", Process, []processTest{
+		{
+			input: `
++------+
+| 日本 |
++------+`,
+			output: `
+┌──────┐
+│ 日本 │
+└──────┘`,
+		},
+		{
+			input:  "+------+\n| cafe\u0301 |\n+------+",
+			output: "┌──────┐\n│ cafe\u0301 │\n└──────┘",
+		},
+		{
+			input:  "+------+\n| 👩\u200d💻👍🏽 |\n+------+",
+			output: "┌──────┐\n│ 👩\u200d💻👍🏽 │\n└──────┘",
+		},
+	})
+}
+
+func TestNewRegionClusters(t *testing.T) {
+	input := "a👩\u200d💻👍🏽b"
+	region := NewRegion([]byte(input))
+	if region.Width() != StringWidth(input) {
+		t.Errorf("region width %d, expected %d", region.Width(),
+			StringWidth(input))
+	}
+	if region.String() != input {
+		t.Errorf("region %q, expected %q", region.String(), input)
+	}
+	for col, ch := range []rune{'a', 0x1F469, 0, 0x1F44D, 0, 'b'} {
+		if region.Get(0, col) != ch {
+			t.Errorf("Get(0, %d)=%q, expected %q", col, region.Get(0, col), ch)
+		}
+	}
+}
@@ -46,6 +46,11 @@ func (assets *Assets) Add(file string, asset os.DirEntry) error {
 	return nil
 }
 
+// Remove removes the file from the assets.
+func (assets *Assets) Remove(file string) {
+	delete(assets.files, file)
+}
+
 // AddDir recursively adds the assets directory. The directory must be
 // located under the assets object's root directory.
 func (assets *Assets) AddDir(dir string) error {
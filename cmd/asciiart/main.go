@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+// Command asciiart converts ASCII art diagrams to Unicode box-drawing
+// characters or to SVG images. The diagram is read from the file
+// argument or from the standard input and the result is written to
+// the standard output. The -reverse flag converts the box-drawing
+// characters back to ASCII art.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/markkurossi/blog/asciiart"
+)
+
+var program = path.Base(os.Args[0])
+
+func main() {
+	log.SetFlags(0)
+
+	style := flag.String("style", "unicode", "output style: unicode, round, svg")
+	reverse := flag.Bool("reverse", false,
+		"reverse mode: convert box-drawing characters to ASCII")
+	flag.Parse()
+
+	var convert func(data string) string
+	if *reverse {
+		convert = asciiart.Reverse
+	} else {
+		switch *style {
+		case "unicode":
+			convert = asciiart.Process
+		case "round":
+			convert = asciiart.Round
+		case "svg":
+			convert = asciiart.SVG
+		default:
+			log.Fatalf("%s: invalid style: %s", program, *style)
+		}
+	}
+
+	var data []byte
+	var err error
+
+	switch flag.NArg() {
+	case 0:
+		data, err = io.ReadAll(os.Stdin)
+	case 1:
+		data, err = os.ReadFile(flag.Arg(0))
+	default:
+		log.Fatalf("usage: %s [-style style] [-reverse] [file]", program)
+	}
+	if err != nil {
+		log.Fatalf("%s: %s", program, err)
+	}
+	result := convert(string(data))
+	fmt.Print(result)
+	if !strings.HasSuffix(result, "\n") {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,252 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// CodeInfo defines the attributes of a fenced code block info
+// string. The info string is a list of filter names, key=value
+// options, and an optional #id anchor. The items are separated by
+// commas or whitespace and the option values can be quoted. Info
+// strings containing whitespace must be enclosed in braces:
+//
+//	```{linenumbers caption="Hello, world!" #lst:hello}
+//
+// The info strings containing whitespace can also be written without
+// the braces, see braceFences.
+//
+// The line number lists of filter arguments can contain commas, so
+// the items consisting of line numbers and ranges continue the
+// previous filter's argument list:
+//
+//	```go,highlight:3-5,9
+type CodeInfo struct {
+	Filters []string
+	Options map[string]string
+	ID      string
+}
+
+// Caption returns the code block caption.
+func (info *CodeInfo) Caption() string {
+	return info.Options["caption"]
+}
+
+// Alt returns the text alternative of the code block.
+func (info *CodeInfo) Alt() string {
+	return info.Options["alt"]
+}
+
+func parseInfo(data []byte) *CodeInfo {
+	info := &CodeInfo{
+		Options: make(map[string]string),
+	}
+	for _, token := range tokenizeInfo(string(data)) {
+		if strings.HasPrefix(token, "#") {
+			info.ID = token[1:]
+			continue
+		}
+		idx := strings.IndexByte(token, '=')
+		if idx > 0 {
+			info.Options[token[:idx]] = unquote(token[idx+1:])
+			continue
+		}
+		last := len(info.Filters) - 1
+		if last >= 0 && isLineList(token) &&
+			strings.IndexByte(info.Filters[last], ':') > 0 {
+			info.Filters[last] += "," + token
+			continue
+		}
+		if last >= 0 && isClassName(token) &&
+			strings.HasPrefix(info.Filters[last], "class:") {
+			// The class:a,b lists continue until the next filter.
+			info.Filters[last] += "," + token
+			continue
+		}
+		info.Filters = append(info.Filters, token)
+	}
+	return info
+}
+
+// braceFences encloses the fenced code block info strings containing
+// whitespace in braces. The Markdown parser does not recognize fences
+// having unbraced info strings with whitespace, so this allows the
+// info strings to be written without the braces:
+//
+//	```go include=main.go lines=10-30
+func braceFences(data []byte) []byte {
+	var result strings.Builder
+
+	for _, line := range scanSource(data) {
+		if !line.fence {
+			result.WriteString(line.text)
+			continue
+		}
+		trimmed := strings.TrimSpace(line.text)
+		rest := strings.TrimLeft(trimmed, trimmed[:1])
+		marker := trimmed[:len(trimmed)-len(rest)]
+		info := strings.TrimSpace(rest)
+		if len(info) == 0 || info[0] == '{' || strings.ContainsRune(info, '`') ||
+			strings.IndexFunc(info, unicode.IsSpace) < 0 {
+			result.WriteString(line.text)
+			continue
+		}
+		indent := line.text[:strings.IndexByte(line.text, trimmed[0])]
+		result.WriteString(indent + marker + "{" + info + "}")
+		if strings.HasSuffix(line.text, "\n") {
+			result.WriteString("\n")
+		}
+	}
+	return []byte(result.String())
+}
+
+// sourceLine defines a line of Markdown source. The code specifies if
+// the line belongs to a fenced or an indented code block and the
+// fence if the line opens a fenced code block.
+type sourceLine struct {
+	text  string
+	code  bool
+	fence bool
+}
+
+var reListItem = regexp.MustCompile(`^(?:[-*+]|\d+[.)])(?:\s|$)`)
+
+// scanSource splits the Markdown source into lines and finds the
+// lines of the fenced and indented code blocks. The fences start
+// with ``` or ~~~ and they end at the next line starting with the
+// same fence. The indented code blocks are lines indented by four or
+// more spaces following a blank line, a heading, or a code block. The
+// fences indented by four or more spaces are lines of indented code
+// blocks. Inside lists, the indented lines are list item content and
+// the fences can have any indentation.
+func scanSource(data []byte) []sourceLine {
+	var lines []sourceLine
+	var fence string
+	var indented, list bool
+	separated := true
+
+	for _, text := range strings.SplitAfter(string(data), "\n") {
+		trimmed := strings.TrimSpace(text)
+		if len(fence) > 0 {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				separated = true
+			}
+			lines = append(lines, sourceLine{
+				text: text,
+				code: true,
+			})
+			continue
+		}
+		if len(trimmed) == 0 {
+			separated = true
+			lines = append(lines, sourceLine{
+				text: text,
+				code: indented,
+			})
+			continue
+		}
+		indent := indentWidth(text)
+		if !list && indent >= 4 && (separated || indented) {
+			indented = true
+			lines = append(lines, sourceLine{
+				text: text,
+				code: true,
+			})
+			continue
+		}
+		indented = false
+
+		if indent < 4 || list {
+			if strings.HasPrefix(trimmed, "```") ||
+				strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				lines = append(lines, sourceLine{
+					text:  text,
+					code:  true,
+					fence: true,
+				})
+				continue
+			}
+		}
+		if reListItem.MatchString(trimmed) {
+			list = true
+		} else if indent == 0 && separated {
+			list = false
+		}
+		separated = strings.HasPrefix(trimmed, "#")
+		lines = append(lines, sourceLine{
+			text: text,
+		})
+	}
+	return lines
+}
+
+// indentWidth returns the width of the line's leading whitespace. The
+// tabs advance to the next multiple of four columns.
+func indentWidth(line string) int {
+	var width int
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+func isClassName(token string) bool {
+	_, ok := filters[token]
+	return !ok && strings.IndexByte(token, ':') < 0
+}
+
+func isLineList(token string) bool {
+	for _, r := range token {
+		if r != '-' && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return len(token) > 0
+}
+
+func tokenizeInfo(info string) []string {
+	var tokens []string
+	var token strings.Builder
+	var quoted bool
+
+	for _, r := range info {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if !quoted && (r == ',' || unicode.IsSpace(r)) {
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+			continue
+		}
+		token.WriteRune(r)
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+func unquote(val string) string {
+	if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
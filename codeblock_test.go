@@ -0,0 +1,85 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		info    string
+		filters []string
+		options map[string]string
+	}{
+		{
+			info:    "go,highlight:3-5,9 linenumbers",
+			filters: []string{"go", "highlight:3-5,9", "linenumbers"},
+		},
+		{
+			info:    "class:a,b,linenumbers",
+			filters: []string{"class:a,b", "linenumbers"},
+		},
+		{
+			info:    "go class:a b highlight:1,2 alt=\"A, B\"",
+			filters: []string{"go", "class:a,b", "highlight:1,2"},
+			options: map[string]string{"alt": "A, B"},
+		},
+	}
+	for _, test := range tests {
+		info := parseInfo([]byte(test.info))
+		if !reflect.DeepEqual(info.Filters, test.filters) {
+			t.Errorf("%q: filters %q, expected %q",
+				test.info, info.Filters, test.filters)
+		}
+		if test.options == nil {
+			test.options = make(map[string]string)
+		}
+		if !reflect.DeepEqual(info.Options, test.options) {
+			t.Errorf("%q: options %q, expected %q",
+				test.info, info.Options, test.options)
+		}
+	}
+}
+
+func TestBraceFences(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "```go include=main.go\n```\n",
+			output: "```{go include=main.go}\n```\n",
+		},
+		{
+			input:  "~~~ go\n~~~\n",
+			output: "~~~ go\n~~~\n",
+		},
+		{
+			input:  "```{go highlight:3}\n```\n",
+			output: "```{go highlight:3}\n```\n",
+		},
+		{
+			// Indented code block.
+			input:  "    ```iql -t uclight\n    ```\n",
+			output: "    ```iql -t uclight\n    ```\n",
+		},
+		{
+			// Indented fence line must not open a fence.
+			input:  "    ```\n    code\n\n```go include=main.go\n```\n",
+			output: "    ```\n    code\n\n```{go include=main.go}\n```\n",
+		},
+	}
+	for _, test := range tests {
+		output := string(braceFences([]byte(test.input)))
+		if output != test.output {
+			t.Errorf("braceFences(%q)=%q, expected %q",
+				test.input, output, test.output)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// Images become numbered figures when their title ends with a
+// {#fig:id} anchor (or with a plain {#fig} without an ID). Code
+// blocks become numbered listings when their info string has a
+// caption option or a #lst:id anchor. The text can refer to labeled
+// figures and listings with @fig:id and @lst:id references.
+var (
+	reFigureTitle   = regexp.MustCompile(`\s*\{#(fig(?::[\w-]+)?)\}\s*$`)
+	reReference     = regexp.MustCompile(`(^|[^\w@])@((?:fig|lst):[\w-]+)`)
+	reReferenceLink = regexp.MustCompile(
+		`<a href="#((?:fig|lst):[\w-]+)">@(?:fig|lst):[\w-]+</a>`)
+)
+
+// parseFigureTitle parses the image title. It returns the figure
+// caption, the optional figure ID, and a boolean indicating if the
+// image is a numbered figure.
+func parseFigureTitle(title string) (string, string, bool) {
+	m := reFigureTitle.FindStringSubmatchIndex(title)
+	if m == nil {
+		return title, "", false
+	}
+	caption := title[:m[0]]
+	id := title[m[2]:m[3]]
+	if id == "fig" {
+		id = ""
+	}
+	return caption, id, true
+}
+
+// isListing tests if the code block is a numbered listing.
+func isListing(info *CodeInfo) bool {
+	return len(info.Caption()) > 0 || strings.HasPrefix(info.ID, "lst:")
+}
+
+// expandReferences converts the @fig:id and @lst:id references into
+// Markdown links which are resolved after all figures and listings
+// are numbered.
+func expandReferences(text string) (string, error) {
+	return reReference.ReplaceAllString(text, "$1[@$2](#$2)"), nil
+}
+
+// Numbering numbers figures and listings.
+type Numbering struct {
+	Figures  int
+	Listings int
+	Labels   map[string]string
+}
+
+// Figure allocates a number for the next figure with the optional
+// ID. The function returns the figure label.
+func (n *Numbering) Figure(id string) (string, error) {
+	n.Figures++
+	return n.label(id, fmt.Sprintf("Figure %d", n.Figures))
+}
+
+// Listing allocates a number for the next listing with the optional
+// ID. The function returns the listing label.
+func (n *Numbering) Listing(id string) (string, error) {
+	n.Listings++
+	return n.label(id, fmt.Sprintf("Listing %d", n.Listings))
+}
+
+func (n *Numbering) label(id, label string) (string, error) {
+	if len(id) == 0 {
+		return label, nil
+	}
+	if n.Labels == nil {
+		n.Labels = make(map[string]string)
+	}
+	if _, ok := n.Labels[id]; ok {
+		return "", fmt.Errorf("duplicate label '%s'", id)
+	}
+	n.Labels[id] = label
+	return label, nil
+}
+
+// Resolve resolves the figure and listing reference links of the
+// HTML data.
+func (n *Numbering) Resolve(data string) (string, error) {
+	var err error
+	result := reReferenceLink.ReplaceAllStringFunc(data, func(a string) string {
+		id := reReferenceLink.FindStringSubmatch(a)[1]
+		label, ok := n.Labels[id]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("reference to unknown label '%s'", id)
+			}
+			return a
+		}
+		return fmt.Sprintf(`<a href="#%s">%s</a>`, id, label)
+	})
+	return result, err
+}
+
+// ResolveText resolves the figure and listing references of the
+// plain text data.
+func (n *Numbering) ResolveText(data string) (string, error) {
+	var err error
+	result := reReference.ReplaceAllStringFunc(data, func(a string) string {
+		m := reReference.FindStringSubmatch(a)
+		label, ok := n.Labels[m[2]]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("reference to unknown label '%s'", m[2])
+			}
+			return a
+		}
+		return m[1] + label
+	})
+	return result, err
+}
+
+func captionHTML(label, caption string) string {
+	if len(caption) == 0 {
+		return label
+	}
+	return label + ": " + html.EscapeString(caption)
+}
+
+func idAttr(id string) string {
+	if len(id) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` id="%s"`, html.EscapeString(id))
+}
+
+// figureImage returns the numbered figure image of the paragraph if
+// the paragraph contains only the figure image. Otherwise the function
+// returns nil. The figures of such paragraphs are rendered in place
+// of the paragraphs since the figure elements can't be inside
+// paragraphs.
+func figureImage(node ast.Node) *ast.Image {
+	if _, ok := node.(*ast.Paragraph); !ok {
+		return nil
+	}
+	var img *ast.Image
+	for _, child := range node.GetChildren() {
+		switch c := child.(type) {
+		case *ast.Image:
+			if img != nil {
+				return nil
+			}
+			img = c
+		case *ast.Text:
+			if len(strings.TrimSpace(string(c.Literal))) > 0 {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	if img == nil {
+		return nil
+	}
+	if _, _, ok := parseFigureTitle(string(img.Title)); !ok {
+		return nil
+	}
+	return img
+}
+
+// imageTitle parses the image title like parseFigureTitle. The
+// numbered figure images must be the only content of their
+// paragraphs, see figureImage. For other images, the function returns
+// the title without the figure anchor.
+func imageTitle(img *ast.Image) (string, string, bool) {
+	caption, id, figure := parseFigureTitle(string(img.Title))
+	if figure && figureImage(img.GetParent()) != img {
+		return caption, "", false
+	}
+	return caption, id, figure
+}
+
+// writeFigure writes a numbered figure with the image attributes and
+// caption.
+func (article *Article) writeFigure(w io.Writer, attrs, alt, caption,
+	id string) {
+
+	label, err := article.Numbering.Figure(id)
+	if err != nil {
+		article.setError(err)
+		return
+	}
+	fmt.Fprintf(w, `<figure%s class="figure"><img %s alt="%s"/>`,
+		idAttr(id), attrs, html.EscapeString(alt))
+	fmt.Fprintf(w, "<figcaption>%s</figcaption></figure>",
+		captionHTML(label, caption))
+}
+
+// beginListing starts a numbered listing.
+func (article *Article) beginListing(w io.Writer, info *CodeInfo) {
+	label, err := article.Numbering.Listing(info.ID)
+	if err != nil {
+		article.setError(err)
+		return
+	}
+	fmt.Fprintf(w, "<figure%s class=\"listing\">\n<figcaption>%s</figcaption>\n",
+		idAttr(info.ID), captionHTML(label, info.Caption()))
+}
+
+// endListing ends a numbered listing.
+func (article *Article) endListing(w io.Writer) {
+	io.WriteString(w, "</figure>\n")
+}
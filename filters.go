@@ -0,0 +1,380 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/markkurossi/blog/asciiart"
+)
+
+// FiltersFile is the template file defining the external command
+// filters.
+const FiltersFile = "filters.toml"
+
+// DefaultFilterTimeout is the default timeout for external command
+// filters.
+const DefaultFilterTimeout = 10 * time.Second
+
+// Code defines a code block for the code block filters. The Data
+// contains plain text unless a filter has converted it to HTML, in
+// which case the HTML is set. The Lang specifies the optional code
+// language. The Highlight contains the 1-based numbers of the
+// highlighted lines and the Focus dims all other lines. The Build
+// reveals the lines one at a time in presentations. The Raw specifies
+// that the HTML data is written in a div element instead of the pre
+// element. The Diagram specifies that the code block is an image
+// having the text alternative Alt and the label summary Summary.
+type Code struct {
+	Data      string
+	Class     string
+	Lang      string
+	HTML      bool
+	Raw       bool
+	Diagram   bool
+	Alt       string
+	Summary   string
+	Highlight map[int]bool
+	Focus     bool
+	Build     bool
+}
+
+// Filter implements a code block filter. The arg is the argument of
+// the name:arg filter references and empty for plain filter names.
+type Filter func(code *Code, arg string) error
+
+var filters = make(map[string]Filter)
+
+// RegisterFilter registers the code block filter with the name. The
+// function panics if the name is already registered.
+func RegisterFilter(name string, filter Filter) {
+	_, ok := filters[name]
+	if ok {
+		panic(fmt.Sprintf("filter %s already registered", name))
+	}
+	filters[name] = filter
+}
+
+func init() {
+	RegisterFilter("ascii-art", filterASCIIArt)
+	RegisterFilter("ascii-svg", filterASCIISVG)
+	RegisterFilter("ascii-table", filterASCIITable)
+	RegisterFilter("linenumbers", filterLinenumbers)
+	RegisterFilter("plain", filterPlain)
+	RegisterFilter("center", filterCenter)
+	RegisterFilter("focus", filterFocus)
+	RegisterFilter("build", filterBuild)
+	RegisterFilter("notes", filterPassthrough)
+	RegisterFilter("class", filterClass)
+	RegisterFilter("highlight", filterHighlight)
+}
+
+// reLanguage matches the code language names. The first item of the
+// code block info string names the code language if it is not a
+// registered filter.
+var reLanguage = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_+#.-]*$`)
+
+func isLanguage(name string) bool {
+	_, ok := filters[name]
+	return !ok && reLanguage.MatchString(name)
+}
+
+// lookupFilter finds the filter of the filter reference name or
+// name:arg. The function returns the filter, its argument, and a
+// boolean indicating if the filter was found.
+func lookupFilter(ref string) (Filter, string, bool) {
+	var arg string
+	idx := strings.IndexByte(ref, ':')
+	if idx >= 0 {
+		arg = ref[idx+1:]
+		ref = ref[:idx]
+	}
+	filter, ok := filters[ref]
+	return filter, arg, ok
+}
+
+func filterASCIIArt(code *Code, arg string) error {
+	d := asciiart.NewDiagram(code.Data)
+	code.Data = d.HTML()
+	code.Class = "ascii-art"
+	code.HTML = true
+	code.Diagram = true
+	code.Summary = d.Summary()
+	return nil
+}
+
+func filterASCIISVG(code *Code, arg string) error {
+	d := asciiart.NewDiagram(code.Data)
+	code.Data = d.SVG()
+	code.Class = "ascii-svg"
+	code.HTML = true
+	code.Diagram = true
+	code.Summary = d.Summary()
+	return nil
+}
+
+// filterASCIITable renders the ASCII grid table as an HTML table. The
+// code blocks which are not grid tables are rendered as ASCII art.
+func filterASCIITable(code *Code, arg string) error {
+	table := parseASCIITable(code.Data)
+	if table == nil {
+		return filterASCIIArt(code, arg)
+	}
+	code.Data = table.HTML()
+	code.Class = "ascii-table"
+	code.HTML = true
+	code.Raw = true
+	return nil
+}
+
+// parseASCIITable parses the grid table of the ascii-table code
+// block. The function returns nil if the data is not a grid table, in
+// which case the code block is rendered as ASCII art.
+func parseASCIITable(data string) *asciiart.Table {
+	table, err := asciiart.ParseTable(data)
+	if err != nil {
+		Verbose(" - ascii-table: %s\n", err)
+		return nil
+	}
+	return table
+}
+
+func filterLinenumbers(code *Code, arg string) error {
+	var result string
+	lines := strings.Split(strings.TrimSpace(code.Data), "\n")
+	var format string
+	if len(lines) > 9 {
+		format = "%2d %s\n"
+	} else {
+		format = "%d %s\n"
+	}
+	for idx, line := range lines {
+		result += fmt.Sprintf(format, idx+1, line)
+	}
+	code.Data = result
+	return nil
+}
+
+func filterPlain(code *Code, arg string) error {
+	code.Class = "code-plain"
+	return nil
+}
+
+func filterCenter(code *Code, arg string) error {
+	code.Class += " center"
+	return nil
+}
+
+func filterFocus(code *Code, arg string) error {
+	code.Focus = true
+	return nil
+}
+
+func filterBuild(code *Code, arg string) error {
+	code.Build = true
+	return nil
+}
+
+func filterPassthrough(code *Code, arg string) error {
+	return nil
+}
+
+func filterClass(code *Code, arg string) error {
+	for _, c := range strings.Split(arg, ",") {
+		code.Class += " "
+		code.Class += c
+	}
+	return nil
+}
+
+// filterHighlight highlights the lines of the comma-separated list of
+// line numbers and line ranges: highlight:3-5,9
+func filterHighlight(code *Code, arg string) error {
+	if code.Highlight == nil {
+		code.Highlight = make(map[int]bool)
+	}
+	for _, r := range strings.Split(arg, ",") {
+		parts := strings.SplitN(r, "-", 2)
+		from, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return fmt.Errorf("invalid line range: %s", r)
+		}
+		to := from
+		if len(parts) == 2 {
+			to, err = strconv.Atoi(parts[1])
+			if err != nil {
+				return fmt.Errorf("invalid line range: %s", r)
+			}
+		}
+		if from < 1 || from > to {
+			return fmt.Errorf("invalid line range: %s", r)
+		}
+		for i := from; i <= to; i++ {
+			code.Highlight[i] = true
+		}
+	}
+	return nil
+}
+
+// ExternalFilter defines an external command filter. The filter
+// command receives the code block on its standard input and returns
+// the filtered code block on its standard output. The filter argument
+// (name:arg) is appended to the command arguments. The Output
+// specifies if the command returns "html" or "text" (the
+// default). The optional Class replaces the code block class. The
+// Timeout is a duration string, such as "30s". The filter outputs are
+// cached in the user's cache directory.
+//
+//	[dot]
+//	Command = ["dot", "-Tsvg"]
+//	Output = "html"
+//	Class = "diagram"
+//	Timeout = "30s"
+type ExternalFilter struct {
+	Command []string
+	Output  string
+	Class   string
+	Timeout string
+
+	timeout time.Duration
+}
+
+// loadFilters loads and registers the external command filters from
+// the file.
+func loadFilters(file string) error {
+	defs := make(map[string]*ExternalFilter)
+	_, err := toml.DecodeFile(file, &defs)
+	if err != nil {
+		return err
+	}
+	for name, def := range defs {
+		if len(def.Command) == 0 {
+			return fmt.Errorf("%s: filter %s: no command", file, name)
+		}
+		switch def.Output {
+		case "", "text", "html":
+		default:
+			return fmt.Errorf("%s: filter %s: invalid output '%s'",
+				file, name, def.Output)
+		}
+		def.timeout = DefaultFilterTimeout
+		if len(def.Timeout) > 0 {
+			def.timeout, err = time.ParseDuration(def.Timeout)
+			if err != nil {
+				return fmt.Errorf("%s: filter %s: %s", file, name, err)
+			}
+		}
+		if _, ok := filters[name]; ok {
+			return fmt.Errorf("%s: filter %s already registered", file, name)
+		}
+		RegisterFilter(name, def.Filter)
+	}
+	return nil
+}
+
+// Filter runs the external filter command for the code block.
+func (def *ExternalFilter) Filter(code *Code, arg string) error {
+	args := append([]string{}, def.Command...)
+	if len(arg) > 0 {
+		args = append(args, arg)
+	}
+	output, err := def.run(args, code.Data)
+	if err != nil {
+		return err
+	}
+	code.Data = output
+	code.HTML = def.Output == "html"
+	if len(def.Class) > 0 {
+		code.Class = def.Class
+	}
+	return nil
+}
+
+func (def *ExternalFilter) run(args []string, input string) (string, error) {
+	cache := filterCacheFile(args, input)
+	if len(cache) > 0 {
+		data, err := os.ReadFile(cache)
+		if err == nil {
+			return string(data), nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), def.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	Verbose(" - exec: %v\n", args)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("%s: timeout after %s", args[0], def.timeout)
+	}
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 0 {
+			return "", fmt.Errorf("%s: %s: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %s", args[0], err)
+	}
+
+	if len(cache) > 0 {
+		err = os.MkdirAll(path.Dir(cache), 0755)
+		if err == nil {
+			err = os.WriteFile(cache, stdout.Bytes(), 0644)
+		}
+		if err != nil {
+			Verbose(" - cache: %s\n", err)
+		}
+	}
+	return stdout.String(), nil
+}
+
+// filterCacheFile returns the cache file name for the filter command
+// and input. The cache key contains the resolved path, modification
+// time, and size of the command executable so that the outputs are
+// not reused when the command changes. The function returns an empty
+// string if the user cache directory or the executable is not
+// available.
+func filterCacheFile(args []string, input string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	file, err := exec.LookPath(args[0])
+	if err != nil {
+		return ""
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:%s:%d:%d", len(file), file, fi.ModTime().UnixNano(),
+		fi.Size())
+	for _, arg := range args {
+		fmt.Fprintf(h, "%d:%s", len(arg), arg)
+	}
+	h.Write([]byte(input))
+
+	return path.Join(dir, "blog", "filters", fmt.Sprintf("%x", h.Sum(nil)))
+}
@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestScript(t *testing.T, file, script string) {
+	err := os.WriteFile(file, []byte("#!/bin/sh\n"+script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+	}{
+		{name: "no command", def: "[f]\noutput = \"text\"\n"},
+		{name: "output", def: "[f]\ncommand = [\"cat\"]\noutput = \"pdf\"\n"},
+		{name: "timeout", def: "[f]\ncommand = [\"cat\"]\ntimeout = \"1x\"\n"},
+		{name: "registered", def: "[plain]\ncommand = [\"cat\"]\n"},
+	}
+	for _, test := range tests {
+		file := path.Join(t.TempDir(), FiltersFile)
+		err := os.WriteFile(file, []byte(test.def), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = loadFilters(file)
+		delete(filters, "f")
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func TestExternalFilterTimeout(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	def := &ExternalFilter{
+		Command: []string{"sleep", "5"},
+		timeout: 100 * time.Millisecond,
+	}
+	start := time.Now()
+	err := def.Filter(&Code{Data: "input"}, "")
+	if err == nil || !strings.Contains(err.Error(), "timeout after 100ms") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("timeout did not stop the command")
+	}
+}
+
+func TestExternalFilterCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	cmd := path.Join(dir, "upper")
+	count := path.Join(dir, "count")
+	writeTestScript(t, cmd, "echo run >> "+count+"\ntr a-z A-Z\n")
+
+	def := &ExternalFilter{
+		Command: []string{cmd},
+		Output:  "html",
+		Class:   "upper",
+		timeout: DefaultFilterTimeout,
+	}
+	runs := func() int {
+		data, err := os.ReadFile(count)
+		if err != nil {
+			return 0
+		}
+		return strings.Count(string(data), "run")
+	}
+	filter := func(input string) {
+		code := &Code{Data: input}
+		err := def.Filter(code, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code.Data != strings.ToUpper(input) || !code.HTML ||
+			code.Class != "upper" {
+			t.Errorf("unexpected filter output: %+v", code)
+		}
+	}
+
+	filter("abc\n")
+	filter("abc\n")
+	if runs() != 1 {
+		t.Errorf("cached output not used: %d runs", runs())
+	}
+	filter("def\n")
+	if runs() != 2 {
+		t.Errorf("input change did not run the filter: %d runs", runs())
+	}
+
+	// Changing the executable invalidates the cached outputs.
+	mtime := time.Now().Add(time.Hour)
+	err := os.Chtimes(cmd, mtime, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter("abc\n")
+	if runs() != 3 {
+		t.Errorf("command change did not run the filter: %d runs", runs())
+	}
+}
+
+func TestFilterCacheFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	cmd := path.Join(dir, "cmd")
+	writeTestScript(t, cmd, "cat\n")
+
+	key := filterCacheFile([]string{cmd, "a"}, "input")
+	if len(key) == 0 {
+		t.Fatalf("no cache file")
+	}
+	if filterCacheFile([]string{cmd, "a"}, "input") != key {
+		t.Errorf("cache file not stable")
+	}
+	for _, other := range []string{
+		filterCacheFile([]string{cmd, "b"}, "input"),
+		filterCacheFile([]string{cmd, "a", ""}, "input"),
+		filterCacheFile([]string{cmd, "a"}, "input2"),
+		filterCacheFile([]string{cmd}, "ainput"),
+	} {
+		if other == key {
+			t.Errorf("different filter runs have the same cache file")
+		}
+	}
+
+	writeTestScript(t, cmd, "cat -\n")
+	if filterCacheFile([]string{cmd, "a"}, "input") == key {
+		t.Errorf("command change did not change the cache file")
+	}
+
+	if len(filterCacheFile([]string{path.Join(dir, "nosuch")}, "")) != 0 {
+		t.Errorf("cache file for a missing command")
+	}
+}
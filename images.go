@@ -0,0 +1,289 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// ImageVariantWidths define the widths of the resized image variants.
+var ImageVariantWidths = []int{480, 960, 1920}
+
+// JPEGQuality defines the JPEG encoding quality for resized images.
+const JPEGQuality = 85
+
+// Image defines an article image which is processed by the responsive
+// image pipeline.
+type Image struct {
+	Src      string
+	Name     string
+	Format   string
+	Width    int
+	Height   int
+	Variants []int
+}
+
+// VariantName returns the name of the image variant with the argument
+// width.
+func (img *Image) VariantName(width int) string {
+	ext := path.Ext(img.Name)
+	return fmt.Sprintf("%s-%dw%s", img.Name[:len(img.Name)-len(ext)], width,
+		ext)
+}
+
+// Attrs returns the HTML img element attributes for the image.
+func (img *Image) Attrs() string {
+	attrs := fmt.Sprintf(`src="%s"`, imageURL(img.Name))
+	if len(img.Variants) > 0 {
+		var srcset []string
+		for _, w := range img.Variants {
+			srcset = append(srcset,
+				fmt.Sprintf("%s %dw", imageURL(img.VariantName(w)), w))
+		}
+		srcset = append(srcset,
+			fmt.Sprintf("%s %dw", imageURL(img.Name), img.Width))
+
+		attrs += fmt.Sprintf(` srcset="%s" sizes="(max-width: %dpx) 100vw, %dpx"`,
+			strings.Join(srcset, ", "), img.Width, img.Width)
+	}
+	return attrs + fmt.Sprintf(` width="%d" height="%d"`, img.Width, img.Height)
+}
+
+// imageURL returns the image path name as an URL which can be used in
+// the HTML attributes.
+func imageURL(name string) string {
+	u := &url.URL{
+		Path: name,
+	}
+	return html.EscapeString(u.String())
+}
+
+// localPath tests if the relative path name stays inside its base
+// directory.
+func localPath(name string) bool {
+	name = path.Clean(name)
+	return !path.IsAbs(name) && name != ".." && !strings.HasPrefix(name, "../")
+}
+
+// image returns the image definition for the image destination. The
+// function returns nil if the destination does not refer to a local
+// PNG or JPEG image in the article directory.
+func (article *Article) image(dest string) *Image {
+	if article.Assets == nil || strings.Contains(dest, ":") ||
+		!localPath(dest) {
+		return nil
+	}
+	if img, ok := article.Images[dest]; ok {
+		return img
+	}
+	src := path.Join(article.Assets.Dir(), dest)
+
+	f, err := os.Open(src)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	config, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil
+	}
+	switch format {
+	case "png", "jpeg":
+	default:
+		return nil
+	}
+
+	img := &Image{
+		Src:    src,
+		Name:   dest,
+		Format: format,
+		Width:  config.Width,
+		Height: config.Height,
+	}
+	for _, w := range ImageVariantWidths {
+		if w < img.Width {
+			img.Variants = append(img.Variants, w)
+		}
+	}
+	if article.Images == nil {
+		article.Images = make(map[string]*Image)
+	}
+	article.Images[dest] = img
+
+	// The image pipeline writes the image to the output directory.
+	article.Assets.Remove(src)
+
+	return img
+}
+
+// processImages creates the article's images and their resized
+// variants to the argument output directory. Images which are newer
+// than their source files are not reprocessed.
+func (article *Article) processImages(dir string) error {
+	for _, img := range article.Images {
+		srcInfo, err := os.Stat(img.Src)
+		if err != nil {
+			return err
+		}
+		output := path.Join(dir, img.Name)
+
+		outputs := []string{output}
+		for _, w := range img.Variants {
+			outputs = append(outputs, path.Join(dir, img.VariantName(w)))
+		}
+		fresh := true
+		for _, o := range outputs {
+			if !isFresh(srcInfo, o) {
+				fresh = false
+				break
+			}
+		}
+		if fresh {
+			continue
+		}
+
+		data, err := os.ReadFile(img.Src)
+		if err != nil {
+			return err
+		}
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %s", img.Src, err)
+		}
+		err = os.MkdirAll(path.Dir(output), 0777)
+		if err != nil {
+			return err
+		}
+
+		// Re-encode PNG images losslessly if it makes them smaller.
+		if img.Format == "png" {
+			encoded, err := img.encode(decoded)
+			if err != nil {
+				return err
+			}
+			if len(encoded) < len(data) {
+				data = encoded
+			}
+		}
+		err = os.WriteFile(output, data, srcInfo.Mode())
+		if err != nil {
+			return err
+		}
+		log.Printf("%s\t=> %s\n", img.Src, output)
+
+		for _, w := range img.Variants {
+			h := img.Height * w / img.Width
+			if h < 1 {
+				h = 1
+			}
+			encoded, err := img.encode(resize(decoded, w, h))
+			if err != nil {
+				return err
+			}
+			variant := path.Join(dir, img.VariantName(w))
+			err = os.WriteFile(variant, encoded, srcInfo.Mode())
+			if err != nil {
+				return err
+			}
+			log.Printf("%s\t=> %s\n", img.Src, variant)
+		}
+	}
+	return nil
+}
+
+func (img *Image) encode(m image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch img.Format {
+	case "png":
+		encoder := &png.Encoder{
+			CompressionLevel: png.BestCompression,
+		}
+		err = encoder.Encode(&buf, m)
+	case "jpeg":
+		err = jpeg.Encode(&buf, m, &jpeg.Options{
+			Quality: JPEGQuality,
+		})
+	default:
+		err = fmt.Errorf("unsupported image format: %s", img.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resize scales the image to the specified size by averaging the
+// source pixels covered by each destination pixel.
+func resize(src image.Image, width, height int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	b := src.Bounds()
+	sw := b.Dx()
+	sh := b.Dy()
+
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*sh/height
+		y1 := b.Min.Y + (y+1)*sh/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*sw/width
+			x1 := b.Min.X + (x+1)*sw/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			// Convert the premultiplied average to non-premultiplied
+			// color.
+			var c color.NRGBA
+			if a > 0 {
+				c = color.NRGBA{
+					R: uint8(r * 0xffff / a >> 8),
+					G: uint8(g * 0xffff / a >> 8),
+					B: uint8(bl * 0xffff / a >> 8),
+					A: uint8(a / n >> 8),
+				}
+			}
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
+
+// isFresh tests if the file exists and it is not older than the
+// source file.
+func isFresh(srcInfo os.FileInfo, file string) bool {
+	info, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().Before(srcInfo.ModTime())
+}
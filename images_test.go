@@ -0,0 +1,131 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestImageAttrs(t *testing.T) {
+	img := &Image{
+		Name:     `a "b" & c.png`,
+		Width:    600,
+		Height:   400,
+		Variants: []int{480},
+	}
+	expected := `src="a%20%22b%22%20&amp;%20c.png"` +
+		` srcset="a%20%22b%22%20&amp;%20c-480w.png 480w,` +
+		` a%20%22b%22%20&amp;%20c.png 600w"` +
+		` sizes="(max-width: 600px) 100vw, 600px" width="600" height="400"`
+	if attrs := img.Attrs(); attrs != expected {
+		t.Errorf("Attrs:\ngot      %s\nexpected %s", attrs, expected)
+	}
+}
+
+func writeTestPNG(t *testing.T, file string, width, height int) {
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	err = png.Encode(f, image.NewNRGBA(image.Rect(0, 0, width, height)))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, path.Join(dir, "wide.png"), 1000, 10)
+	writeTestPNG(t, path.Join(dir, "small.png"), 100, 10)
+	os.WriteFile(path.Join(dir, "text.png"), []byte("not an image"), 0644)
+
+	article := NewArticle(0)
+	article.Assets = NewAssets(dir)
+
+	tests := []struct {
+		dest     string
+		variants []int
+		ok       bool
+	}{
+		{"wide.png", []int{480, 960}, true},
+		{"small.png", nil, true},
+		{"text.png", nil, false},
+		{"missing.png", nil, false},
+		{"../wide.png", nil, false},
+		{"/wide.png", nil, false},
+		{"https://example.com/wide.png", nil, false},
+	}
+	for _, test := range tests {
+		img := article.image(test.dest)
+		if (img != nil) != test.ok {
+			t.Errorf("image(%s): got %v, expected %v", test.dest, img, test.ok)
+			continue
+		}
+		if img != nil && !reflect.DeepEqual(img.Variants, test.variants) {
+			t.Errorf("image(%s): variants %v, expected %v",
+				test.dest, img.Variants, test.variants)
+		}
+	}
+}
+
+func TestProcessImages(t *testing.T) {
+	dir := t.TempDir()
+	out := t.TempDir()
+	src := path.Join(dir, "wide.png")
+	writeTestPNG(t, src, 1000, 10)
+
+	article := NewArticle(0)
+	article.Assets = NewAssets(dir)
+	if article.image("wide.png") == nil {
+		t.Fatal("image not found")
+	}
+	if err := article.processImages(out); err != nil {
+		t.Fatal(err)
+	}
+	outputs := []string{"wide.png", "wide-480w.png", "wide-960w.png"}
+	for _, o := range outputs {
+		if _, err := os.Stat(path.Join(out, o)); err != nil {
+			t.Errorf("%s not created: %s", o, err)
+		}
+	}
+
+	// The outputs newer than the source are not reprocessed.
+	old := time.Now().Add(-time.Hour)
+	older := old.Add(-time.Hour)
+	os.Chtimes(src, older, older)
+	for _, o := range outputs {
+		os.Chtimes(path.Join(out, o), old, old)
+	}
+	if err := article.processImages(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outputs {
+		info, err := os.Stat(path.Join(out, o))
+		if err != nil || !info.ModTime().Equal(old) {
+			t.Errorf("%s: fresh output reprocessed", o)
+		}
+	}
+
+	// All outputs are recreated if a variant is missing.
+	os.Remove(path.Join(out, "wide-480w.png"))
+	if err := article.processImages(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outputs {
+		info, err := os.Stat(path.Join(out, o))
+		if err != nil || info.ModTime().Equal(old) {
+			t.Errorf("%s: output not recreated", o)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	mdast "github.com/gomarkdown/markdown/ast"
+)
+
+// includeCode replaces the contents of the code blocks having the
+// include option with the contents of the included files. The
+// included file is resolved relative to the article directory. The
+// lines option selects a line range (10-30, 10-, or 10) from the file
+// and the func option selects a named Go function or method
+// (Type.Method) with its doc comment:
+//
+//	```go include=main.go lines=10-30
+//	```{go include=main.go func=main}
+func (article *Article) includeCode(dir string, doc mdast.Node) error {
+	var err error
+	mdast.WalkFunc(doc, func(node mdast.Node, entering bool) mdast.WalkStatus {
+		code, ok := node.(*mdast.CodeBlock)
+		if !ok || !entering || err != nil {
+			return mdast.GoToNext
+		}
+		info := parseInfo(code.Info)
+		file, ok := info.Options["include"]
+		if !ok {
+			return mdast.GoToNext
+		}
+		var data string
+		data, err = readInclude(dir, file, info)
+		if err != nil {
+			err = fmt.Errorf("include %s: %s", file, err)
+			return mdast.Terminate
+		}
+		code.Literal = []byte(data)
+		return mdast.GoToNext
+	})
+	return err
+}
+
+func readInclude(dir, file string, info *CodeInfo) (string, error) {
+	if !localPath(file) {
+		return "", fmt.Errorf("file outside article directory")
+	}
+	file = path.Clean(file)
+	data, err := os.ReadFile(path.Join(dir, file))
+	if err != nil {
+		return "", err
+	}
+	lines, hasLines := info.Options["lines"]
+	fn, hasFunc := info.Options["func"]
+
+	switch {
+	case hasLines && hasFunc:
+		return "", fmt.Errorf("lines and func are mutually exclusive")
+
+	case hasLines:
+		return selectLines(string(data), lines)
+
+	case hasFunc:
+		return selectFunc(file, data, fn)
+
+	default:
+		return string(data), nil
+	}
+}
+
+func selectLines(data, spec string) (string, error) {
+	lines := strings.Split(strings.TrimSuffix(data, "\n"), "\n")
+
+	to := len(lines)
+
+	parts := strings.SplitN(spec, "-", 2)
+	from, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("invalid line range: %s", spec)
+	}
+	if len(parts) == 1 {
+		to = from
+	} else if len(parts[1]) > 0 {
+		to, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid line range: %s", spec)
+		}
+	}
+	if from < 1 || from > to || to > len(lines) {
+		return "", fmt.Errorf("line range %s outside file (1-%d)",
+			spec, len(lines))
+	}
+	return strings.Join(lines[from-1:to], "\n") + "\n", nil
+}
+
+func selectFunc(file string, data []byte, name string) (string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, data, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+	for _, decl := range f.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || funcName(fd) != name {
+			continue
+		}
+		start := fd.Pos()
+		if fd.Doc != nil {
+			start = fd.Doc.Pos()
+		}
+		return string(data[fset.Position(start).Offset:fset.Position(fd.End()).Offset]) +
+			"\n", nil
+	}
+	return "", fmt.Errorf("function %s not found", name)
+}
+
+func funcName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	t := fd.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	if idx, ok := t.(*ast.IndexExpr); ok {
+		t = idx.X
+	}
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name + "." + fd.Name.Name
+	}
+	return fd.Name.Name
+}
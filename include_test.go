@@ -0,0 +1,198 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+var includeSource = `package main
+
+// Point is a point.
+type Point struct {
+	X, Y int
+}
+
+// String implements Stringer.
+func (p *Point) String() string {
+	return "point"
+}
+
+func main() {
+}
+`
+
+func TestSelectLines(t *testing.T) {
+	data := "a\nb\nc\nd\n"
+	tests := []struct {
+		spec   string
+		output string
+	}{
+		{spec: "2", output: "b\n"},
+		{spec: "2-3", output: "b\nc\n"},
+		{spec: "3-", output: "c\nd\n"},
+		{spec: "1-4", output: data},
+		{spec: "4-4", output: "d\n"},
+		{spec: "0"},
+		{spec: "5"},
+		{spec: "3-5"},
+		{spec: "3-2"},
+		{spec: "-2"},
+		{spec: "a-b"},
+		{spec: "1-b"},
+	}
+	for _, test := range tests {
+		output, err := selectLines(data, test.spec)
+		if len(test.output) == 0 {
+			if err == nil {
+				t.Errorf("selectLines(%q): expected an error, got %q",
+					test.spec, output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("selectLines(%q): %s", test.spec, err)
+			continue
+		}
+		if output != test.output {
+			t.Errorf("selectLines(%q)=%q, expected %q",
+				test.spec, output, test.output)
+		}
+	}
+}
+
+func TestSelectFunc(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{
+			name:   "main",
+			output: "func main() {\n}\n",
+		},
+		{
+			name: "Point.String",
+			output: `// String implements Stringer.
+func (p *Point) String() string {
+	return "point"
+}
+`,
+		},
+		{name: "String"},
+		{name: "Point"},
+		{name: "nosuch"},
+	}
+	for _, test := range tests {
+		output, err := selectFunc("main.go", []byte(includeSource), test.name)
+		if len(test.output) == 0 {
+			if err == nil {
+				t.Errorf("selectFunc(%q): expected an error, got %q",
+					test.name, output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("selectFunc(%q): %s", test.name, err)
+			continue
+		}
+		if output != test.output {
+			t.Errorf("selectFunc(%q)=%q, expected %q",
+				test.name, output, test.output)
+		}
+	}
+}
+
+func TestIncludeCode(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(path.Join(dir, "main.go"), []byte(includeSource),
+		0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "```go include=main.go\n```\n",
+			output: includeSource,
+		},
+		{
+			input:  "```go include=main.go lines=13-14\n```\n",
+			output: "func main() {\n}\n",
+		},
+		{
+			input:  "```go include=main.go func=main\n```\n",
+			output: "func main() {\n}\n",
+		},
+		{
+			input:  "```go include=./main.go func=Point.String\n```\n",
+			output: "// String implements Stringer.\n",
+		},
+		{
+			input: "```go include=main.go lines=13-15\n```\n",
+		},
+		{
+			input: "```go include=main.go lines=2 func=main\n```\n",
+		},
+		{
+			input: "```go include=main.go func=nosuch\n```\n",
+		},
+		{
+			input: "```go include=nosuch.go\n```\n",
+		},
+		{
+			input: "```go include=../main.go\n```\n",
+		},
+		{
+			input: "```go include=sub/../../main.go\n```\n",
+		},
+		{
+			input: "```go include=" + path.Join(dir, "main.go") + "\n```\n",
+		},
+	}
+	for _, test := range tests {
+		article := NewArticle(parser.CommonExtensions)
+		doc := markdown.Parse(braceFences([]byte(test.input)),
+			parser.NewWithExtensions(article.Extensions))
+
+		err := article.includeCode(dir, doc)
+		if len(test.output) == 0 {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", test.input, err)
+			continue
+		}
+		var code *ast.CodeBlock
+		ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+			if c, ok := node.(*ast.CodeBlock); ok {
+				code = c
+			}
+			return ast.GoToNext
+		})
+		if code == nil {
+			t.Errorf("%q: no code block", test.input)
+			continue
+		}
+		if !strings.HasPrefix(string(code.Literal), test.output) {
+			t.Errorf("%q: got %q, expected %q",
+				test.input, code.Literal, test.output)
+		}
+	}
+}
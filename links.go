@@ -0,0 +1,109 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+)
+
+// Articles can link to other articles with the [[name]] syntax or
+// with Markdown links having the article:name destination. The links
+// are resolved to the target articles' output paths and titles at
+// generate time.
+var (
+	reWikiLink    = regexp.MustCompile(`\[\[([^\[\]|]+)\]\]`)
+	reArticleLink = regexp.MustCompile(
+		`<a ([^>]*?)href="article:([^"]*)"([^>]*)>((?s:.*?))</a>`)
+	reUnresolvedLink = regexp.MustCompile(`href="(article:[^"]*)"`)
+)
+
+// articleNames maps article names to all parsed articles, including
+// drafts. Ambiguous names map to nil.
+var articleNames = make(map[string]*Article)
+
+// registerArticleName registers the article for article links.
+func registerArticleName(article *Article) {
+	_, ok := articleNames[article.Name]
+	if ok {
+		articleNames[article.Name] = nil
+	} else {
+		articleNames[article.Name] = article
+	}
+}
+
+// expandWikiLinks converts the [[name]] links into article: links.
+// The names are escaped in the link destinations so that the names
+// can contain spaces.
+func expandWikiLinks(text string) (string, error) {
+	return reWikiLink.ReplaceAllStringFunc(text, func(link string) string {
+		name := reWikiLink.FindStringSubmatch(link)[1]
+		return fmt.Sprintf("[article:%s](article:%s)", name,
+			url.PathEscape(name))
+	}), nil
+}
+
+// ResolveLinks resolves the article's links to other articles and
+// its figure and listing references.
+func (article *Article) ResolveLinks() error {
+	for k, v := range article.Values {
+		v, err := article.Numbering.Resolve(v)
+		if err != nil {
+			return fmt.Errorf("%s: %s", article.Name, err)
+		}
+		resolved := reArticleLink.ReplaceAllStringFunc(v, func(a string) string {
+			if err != nil {
+				return a
+			}
+			m := reArticleLink.FindStringSubmatch(a)
+			var href, text string
+			href, text, err = article.resolveLink(m[2], m[4])
+			return fmt.Sprintf(`<a %shref="%s"%s>%s</a>`,
+				m[1], href, m[3], text)
+		})
+		if m := reUnresolvedLink.FindStringSubmatch(resolved); err == nil &&
+			m != nil {
+			err = fmt.Errorf("unresolved article link '%s'", m[1])
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %s", article.Name, err)
+		}
+		article.Values[k] = resolved
+	}
+	return nil
+}
+
+// resolveLink resolves the link to the article name. The name is the
+// escaped href value of the link. The function returns the link's
+// href and text.
+func (article *Article) resolveLink(name, text string) (
+	string, string, error) {
+
+	name, err := url.PathUnescape(html.UnescapeString(name))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid article link: %s", err)
+	}
+	target, ok := articleNames[name]
+	if !ok {
+		return "", "", fmt.Errorf("link to unknown article '%s'", name)
+	}
+	if target == nil {
+		return "", "", fmt.Errorf("link to ambiguous article '%s'", name)
+	}
+	if !target.Published && (article.Published || !flagDraft) {
+		return "", "", fmt.Errorf("link to draft article '%s'", name)
+	}
+	if html.UnescapeString(text) == "article:"+name {
+		text = target.Title()
+	}
+	href := &url.URL{
+		Path: article.Values[ValOutputDir] + target.OutputName(),
+	}
+	return html.EscapeString(href.String()), text, nil
+}
@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomarkdown/markdown/parser"
+)
+
+func TestResolveLinks(t *testing.T) {
+	saved := articleNames
+	defer func() {
+		articleNames = saved
+	}()
+	articleNames = make(map[string]*Article)
+
+	for _, name := range []string{"foo", "my post", "draft", "dup", "dup"} {
+		target := NewArticle(parser.CommonExtensions)
+		target.Name = name
+		target.Timestamp = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		target.Published = name != "draft"
+		target.Values.Set(ValTitle, strings.ToUpper(name)+" & co")
+		registerArticleName(target)
+	}
+
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "[[foo]]",
+			output: `<a href="../2024-01-02/foo.html">FOO &amp; co</a>`,
+		},
+		{
+			input:  "[[my post]]",
+			output: `<a href="../2024-01-02/my%20post.html">MY POST &amp; co</a>`,
+		},
+		{
+			input:  `[text](article:foo "Title")`,
+			output: `<a href="../2024-01-02/foo.html" title="Title">text</a>`,
+		},
+		{
+			input:  "[long\ntext](article:foo)",
+			output: "<a href=\"../2024-01-02/foo.html\">long\ntext</a>",
+		},
+	}
+	for _, test := range tests {
+		values, err := formatLinks(test.input)
+		if err != nil {
+			t.Errorf("%q: %s", test.input, err)
+			continue
+		}
+		if !strings.Contains(values, test.output) {
+			t.Errorf("%q: got %s, expected %s", test.input, values, test.output)
+		}
+	}
+
+	for _, input := range []string{
+		"[[nosuch]]",
+		"[[draft]]",
+		"[[dup]]",
+		"[text](article:nosuch)",
+		`<a href="article:foo">unclosed`,
+	} {
+		_, err := formatLinks(input)
+		if err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func formatLinks(input string) (string, error) {
+	article := NewArticle(parser.CommonExtensions)
+	article.Name = "test"
+	article.Published = true
+	article.Values[ValOutputDir] = "../"
+
+	data, err := article.format([]byte(input))
+	if err != nil {
+		return "", err
+	}
+	article.Values["Content"] = string(data)
+	err = article.ResolveLinks()
+	if err != nil {
+		return "", err
+	}
+	return article.Values["Content"], nil
+}
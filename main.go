@@ -27,6 +27,8 @@ var (
 	flagRTF     bool
 	flagSite    bool
 	flagLibrary string
+
+	flagStandalone bool
 )
 
 func main() {
@@ -39,6 +41,8 @@ func main() {
 	flag.BoolVar(&flagDraft, "draft", false, "process draft articles")
 	flag.BoolVar(&flagRTF, "rtf", false, "generate RTF output")
 	flag.BoolVar(&flagSite, "site", false, "site mode")
+	flag.BoolVar(&flagStandalone, "standalone", false,
+		"generate self-contained presentation files")
 	flag.StringVar(&flagLibrary, "lib", ".", "asset library path")
 
 	flag.Parse()
@@ -192,6 +196,7 @@ func processArticle(dir string) error {
 	if article.IsIndex() {
 		index = article
 	} else {
+		registerArticleName(article)
 		if article.Published || flagDraft {
 			articles = append(articles, article)
 			tags.Merge(article.Tags)
@@ -237,6 +242,19 @@ func makeOutput(out string) error {
 		}
 	}
 
+	// Resolve links between articles once all output folders are
+	// known.
+	for _, article := range articles {
+		if err := article.ResolveLinks(); err != nil {
+			return err
+		}
+	}
+	if index != nil {
+		if err := index.ResolveLinks(); err != nil {
+			return err
+		}
+	}
+
 	var indexLinks string
 
 	Verbose("Generate\n")
@@ -10,17 +10,37 @@ import (
 	"fmt"
 	"html"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/ast"
 	mdhtml "github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
-	"github.com/markkurossi/blog/asciiart"
 )
 
-func (article *Article) format(data []byte) []byte {
-	parser := parser.NewWithExtensions(article.Extensions)
+func (article *Article) format(data []byte) ([]byte, error) {
+	data, err := mapSource(data, func(text string) (string, error) {
+		text, err := article.expandShortcodes(text)
+		if err != nil {
+			return "", err
+		}
+		text, err = expandWikiLinks(text)
+		if err != nil {
+			return "", err
+		}
+		return expandReferences(text)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := article.Extensions
+	if article.Type() == TmplPresentation {
+		// The slide headings parse their own {#id} attributes.
+		extensions &^= parser.HeadingIDs
+	}
+	parser := parser.NewWithExtensions(extensions)
 
 	opts := mdhtml.RendererOptions{
 		Flags: mdhtml.CommonFlags,
@@ -29,29 +49,290 @@ func (article *Article) format(data []byte) []byte {
 	case TmplPresentation:
 		opts.RenderNodeHook = article.renderPresentation
 	case TmplArticle:
-		opts.RenderNodeHook = renderArticle
+		opts.RenderNodeHook = article.renderArticle
 	}
 
 	renderer := mdhtml.NewRenderer(opts)
 
-	return markdown.ToHTML(data, parser, renderer)
+	doc := markdown.Parse(braceFences(data), parser)
+	if article.Assets != nil {
+		err = article.includeCode(article.Assets.Dir(), doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	article.formatErr = nil
+	result := markdown.Render(doc, renderer)
+	if article.formatErr != nil {
+		return nil, article.formatErr
+	}
+	return result, nil
 }
 
-func renderArticle(w io.Writer, node ast.Node, entering bool) (
-	ast.WalkStatus, bool) {
+// setError sets the article formatting error. Only the first error
+// is recorded.
+func (article *Article) setError(err error) {
+	if article.formatErr == nil {
+		article.formatErr = err
+	}
+}
+
+// mapSource calls the function f for all Markdown source text
+// fragments which are outside code blocks and inline code spans, see
+// scanSource. The function returns the source where the fragments are
+// replaced with the values returned by f.
+func mapSource(data []byte, f func(text string) (string, error)) (
+	[]byte, error) {
+
+	var result strings.Builder
+
+	for _, line := range scanSource(data) {
+		if line.code {
+			result.WriteString(line.text)
+			continue
+		}
+		parts := strings.Split(line.text, "`")
+		for idx, part := range parts {
+			if idx > 0 {
+				result.WriteString("`")
+			}
+			if idx%2 == 1 && idx < len(parts)-1 {
+				// Inline code span.
+				result.WriteString(part)
+				continue
+			}
+			mapped, err := f(part)
+			if err != nil {
+				return nil, err
+			}
+			result.WriteString(mapped)
+		}
+	}
+	return []byte(result.String()), nil
+}
+
+func (article *Article) renderArticle(w io.Writer, node ast.Node,
+	entering bool) (ast.WalkStatus, bool) {
+
+	if img := figureImage(node); img != nil {
+		if entering {
+			article.renderArticle(w, img, true)
+			io.WriteString(w, "\n")
+		}
+		return ast.SkipChildren, true
+	}
+	if n, ok := node.(*ast.Image); ok {
+		caption, id, figure := imageTitle(n)
+		img := article.image(string(n.Destination))
+		if img == nil && !figure && caption == string(n.Title) {
+			return ast.GoToNext, false
+		}
+		if !entering {
+			return ast.SkipChildren, true
+		}
+		attrs := fmt.Sprintf(`src="%s"`, html.EscapeString(string(n.Destination)))
+		if img != nil {
+			attrs = img.Attrs()
+		}
+		if figure {
+			article.writeFigure(w, attrs, nodeText(n), caption, id)
+			return ast.SkipChildren, true
+		}
+		fmt.Fprintf(w, `<img %s alt="%s"`, attrs,
+			html.EscapeString(nodeText(n)))
+		if len(caption) > 0 {
+			fmt.Fprintf(w, ` title="%s"`, html.EscapeString(caption))
+		}
+		io.WriteString(w, "/>")
+		return ast.SkipChildren, true
+	}
+
 	code, ok := node.(*ast.CodeBlock)
 	if !ok {
 		return ast.GoToNext, false
 	}
-	io.WriteString(w, "<pre>\n")
-	if len(code.Info) > 0 {
-		io.WriteString(w, fmt.Sprintf("%s:\n%s",
-			code.Info, html.EscapeString(string(code.Literal))))
-	} else {
-		io.WriteString(w, html.EscapeString(string(code.Literal)))
+	article.renderCode(w, parseInfo(code.Info), code.Literal)
+	return ast.GoToNext, true
+}
+
+// renderCode renders the code block through its filter chain. The
+// same filters are applied for articles and presentations.
+func (article *Article) renderCode(w io.Writer, info *CodeInfo,
+	literal []byte) {
+
+	code := &Code{
+		Data:  string(literal),
+		Class: "code",
+		Alt:   info.Alt(),
+	}
+	listing := isListing(info)
+	if listing {
+		article.beginListing(w, info)
+	}
+	for idx, f := range info.Filters {
+		Verbose(" - filter: %v\n", f)
+		filter, arg, ok := lookupFilter(f)
+		if !ok {
+			if idx == 0 && isLanguage(f) {
+				// The first item can name the code language.
+				code.Lang = f
+				continue
+			}
+			article.setError(fmt.Errorf("unknown code block filter '%s'", f))
+			continue
+		}
+		err := filter(code, arg)
+		if err != nil {
+			article.setError(fmt.Errorf("filter %s: %s", f, err))
+		}
+	}
+
+	switch {
+	case code.Raw:
+		fmt.Fprintf(w, "<div class=\"%s\">\n%s\n</div>\n", code.Class, code.Data)
+
+	case code.Diagram:
+		writeDiagram(w, code)
+
+	default:
+		writePre(w, code, "")
+	}
+	if listing {
+		article.endListing(w)
+	}
+}
+
+// writeDiagram writes the diagram code block inside a figure
+// element. The diagram is labeled with its text alternative or with
+// its label summary. If the diagram has both, the summary is written
+// as the figure caption.
+func writeDiagram(w io.Writer, code *Code) {
+	label := code.Alt
+	if len(label) == 0 {
+		label = code.Summary
+	}
+	if len(label) == 0 {
+		label = "Diagram"
+	}
+	io.WriteString(w, "<figure class=\"diagram\">\n")
+	writePre(w, code, fmt.Sprintf(` role="img" aria-label="%s"`,
+		html.EscapeString(label)))
+	if len(code.Summary) > 0 && code.Summary != label {
+		fmt.Fprintf(w, "<figcaption class=\"diagram-summary\">%s</figcaption>\n",
+			html.EscapeString(code.Summary))
+	}
+	io.WriteString(w, "</figure>\n")
+}
+
+// writePre writes the code block lines inside a pre element with the
+// additional attributes attrs. The code language is written as the
+// language-<lang> class.
+func writePre(w io.Writer, code *Code, attrs string) {
+	class := code.Class
+	if len(code.Lang) > 0 {
+		class += " language-" + code.Lang
+	}
+	fmt.Fprintf(w, "<pre class=\"%s\"%s>\n", class, attrs)
+	lines := strings.Split(strings.TrimSuffix(code.Data, "\n"), "\n")
+	for idx, line := range lines {
+		if !code.HTML {
+			line = html.EscapeString(line)
+		}
+		var classes []string
+		if code.Build {
+			// Reveal the code lines one at a time.
+			classes = append(classes, "step")
+		}
+		if code.Highlight[idx+1] {
+			classes = append(classes, "hl")
+		} else if code.Focus && len(code.Highlight) > 0 {
+			classes = append(classes, "dim")
+		}
+		if len(classes) > 0 {
+			fmt.Fprintf(w, "<span class=\"%s\">%s\n</span>",
+				strings.Join(classes, " "), line)
+		} else {
+			fmt.Fprintf(w, "%s\n", line)
+		}
 	}
 	io.WriteString(w, "</pre>\n")
-	return ast.GoToNext, true
+}
+
+// nodeText returns the text content of the node's children.
+func nodeText(node ast.Node) string {
+	var result string
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if leaf := n.AsLeaf(); leaf != nil && entering {
+			result += string(leaf.Literal)
+		}
+		return ast.GoToNext
+	})
+	return result
+}
+
+// parseHeadingAttrs parses and removes the trailing {...} attributes
+// of the heading. The attributes have the same syntax as code block
+// info strings:
+//
+//	### Column title {width=2}
+func parseHeadingAttrs(heading *ast.Heading) *CodeInfo {
+	children := heading.GetChildren()
+	if len(children) == 0 {
+		return parseInfo(nil)
+	}
+	text, ok := children[len(children)-1].(*ast.Text)
+	if !ok {
+		return parseInfo(nil)
+	}
+	literal := strings.TrimRight(string(text.Literal), " \t")
+	start := strings.LastIndexByte(literal, '{')
+	if start < 0 || !strings.HasSuffix(literal, "}") {
+		return parseInfo(nil)
+	}
+	attrs := literal[start+1 : len(literal)-1]
+	if !isHeadingAttrs(attrs) {
+		return parseInfo(nil)
+	}
+	text.Literal = []byte(strings.TrimRight(literal[:start], " \t"))
+
+	return parseInfo([]byte(attrs))
+}
+
+// headingAttrKeys define the known heading attribute options.
+var headingAttrKeys = map[string]bool{
+	"bg":     true,
+	"layout": true,
+	"width":  true,
+}
+
+// isHeadingAttrs tests if the heading's trailing {...} text is an
+// attribute list. The attribute lists start with a .class, an #id,
+// the build flag, or a known key=value option. Other braced texts,
+// such as {T}, are kept in the heading.
+func isHeadingAttrs(attrs string) bool {
+	tokens := tokenizeInfo(attrs)
+	if len(tokens) == 0 {
+		return false
+	}
+	first := tokens[0]
+	if len(first) > 1 && (first[0] == '.' || first[0] == '#') {
+		return true
+	}
+	if first == "build" {
+		return true
+	}
+	idx := strings.IndexByte(first, '=')
+	return idx > 0 && headingAttrKeys[first[:idx]]
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
 }
 
 func className(pagenum int) string {
@@ -91,18 +372,20 @@ func (article *Article) renderPresentation(w io.Writer, node ast.Node,
 
 			if entering {
 				if article.Pagenum > 0 {
-					if article.Pagenum == 1 {
-						fmt.Fprintf(w, "%s</div>\n", I(1))
-					} else {
-						fmt.Fprintf(w,
-							"%s<span class=\"pagenumber\">%d</span>\n",
-							I(1), article.Pagenum)
-					}
-					fmt.Fprintf(w, "%s</article>\n\n", I(0))
+					article.endSlide(w)
 				}
+				attrs := parseHeadingAttrs(n)
+				article.buildSlide = hasFlag(attrs.Filters, "build")
+				// The build markers do not continue to the next slide.
+				article.buildList = false
+				article.listBuilds = nil
 
-				fmt.Fprintf(w, "%s<article class=\"%s\">\n",
-					I(0), className(article.Pagenum))
+				slideAttrs, err := article.slideAttrs(attrs)
+				if err != nil {
+					article.setError(fmt.Errorf("slide '%s': %s",
+						nodeText(n), err))
+				}
+				fmt.Fprintf(w, "%s<article%s>\n", I(0), slideAttrs)
 				fmt.Fprintf(w, "%s<%s>", I(1), tag)
 			} else {
 				fmt.Fprintf(w, "</%s>\n", tag)
@@ -111,34 +394,104 @@ func (article *Article) renderPresentation(w io.Writer, node ast.Node,
 				}
 				article.Pagenum++
 			}
-		} else {
+		} else if n.Level == 3 {
 			// Slide column.
+			if entering {
+				attrs := parseHeadingAttrs(n)
+				article.beginColumn(w, attrs)
+				if len(strings.TrimSpace(nodeText(n))) == 0 {
+					return ast.SkipChildren, true
+				}
+				fmt.Fprintf(w, "%s<h4>", I(2))
+			} else if len(strings.TrimSpace(nodeText(n))) > 0 {
+				fmt.Fprintf(w, "</h4>\n")
+			}
+		} else {
+			return ast.GoToNext, false
 		}
 		return ast.GoToNext, true
 
-	case *ast.CodeBlock:
-		data := string(n.Literal)
-		class := "code"
-		var err error
+	case *ast.Document:
+		if !entering && article.Pagenum > 0 {
+			article.endSlide(w)
+		}
+		return ast.GoToNext, false
 
-		for _, f := range strings.Split(string(n.Info), ",") {
-			Verbose(" - filter: %v\n", f)
-			data, class, err = filter(f)(data, class)
-			if err != nil {
-				fmt.Printf("filter %s: %s\n", f, err)
+	case *ast.HTMLBlock:
+		if strings.TrimSpace(string(n.Literal)) == "<!-- build -->" {
+			// Reveal the items of the next list one at a time.
+			article.buildList = true
+			return ast.GoToNext, true
+		}
+		return ast.GoToNext, false
+
+	case *ast.List:
+		if entering {
+			build := article.buildSlide || article.buildList
+			if len(article.listBuilds) > 0 {
+				build = build || article.listBuilds[len(article.listBuilds)-1]
 			}
+			article.buildList = false
+			article.listBuilds = append(article.listBuilds, build)
+		} else {
+			article.listBuilds = article.listBuilds[:len(article.listBuilds)-1]
 		}
+		return ast.GoToNext, false
 
-		fmt.Fprintf(w, "<pre class=\"%s\">\n", class)
-		io.WriteString(w, html.EscapeString(data))
-		io.WriteString(w, "</pre>\n")
+	case *ast.ListItem:
+		if len(article.listBuilds) == 0 ||
+			!article.listBuilds[len(article.listBuilds)-1] ||
+			n.ListFlags&(ast.ListTypeTerm|ast.ListTypeDefinition) != 0 {
+			return ast.GoToNext, false
+		}
+		if entering {
+			io.WriteString(w, `<li class="step">`)
+		} else {
+			io.WriteString(w, "</li>\n")
+		}
 		return ast.GoToNext, true
 
+	case *ast.CodeBlock:
+		info := parseInfo(n.Info)
+		if len(info.Filters) > 0 && info.Filters[0] == "notes" {
+			// Speaker notes are emitted after the slide.
+			article.slideNotes = append(article.slideNotes,
+				markdown.ToHTML(n.Literal,
+					parser.NewWithExtensions(article.Extensions), nil)...)
+			return ast.GoToNext, true
+		}
+		article.renderCode(w, info, n.Literal)
+		return ast.GoToNext, true
+
+	case *ast.Paragraph:
+		img := figureImage(n)
+		if img == nil {
+			return ast.GoToNext, false
+		}
+		if entering {
+			article.renderPresentation(w, img, true)
+			io.WriteString(w, "\n")
+		}
+		return ast.SkipChildren, true
+
 	case *ast.Image:
+		caption, id, figure := imageTitle(n)
 		if entering {
-			fmt.Fprintf(w, `<p align="center"><img src="%s" title="%s"/>`,
-				string(n.Destination), html.EscapeString(string(n.Title)))
-		} else {
+			attrs := fmt.Sprintf(`src="%s"`,
+				html.EscapeString(string(n.Destination)))
+			if img := article.image(string(n.Destination)); img != nil {
+				attrs = img.Attrs()
+			}
+			if article.buildSlide {
+				attrs += ` class="step"`
+			}
+			if figure {
+				article.writeFigure(w, attrs, nodeText(n), caption, id)
+			} else {
+				fmt.Fprintf(w, `<p align="center"><img %s title="%s"/>`,
+					attrs, html.EscapeString(caption))
+			}
+		} else if !figure {
 			fmt.Fprintf(w, "</p>\n")
 		}
 		return ast.SkipChildren, true
@@ -148,62 +501,51 @@ func (article *Article) renderPresentation(w io.Writer, node ast.Node,
 	}
 }
 
-func filter(name string) func(data, class string) (string, string, error) {
-	switch name {
-	case "ascii-art":
-		return filterASCIIArt
-
-	case "linenumbers":
-		return filterLinenumbers
-
-	case "plain":
-		return filterPlain
-
-	case "center":
-		return filterCenter
+// beginColumn starts a new slide column. The width attribute
+// specifies the column's relative width.
+func (article *Article) beginColumn(w io.Writer, attrs *CodeInfo) {
+	if article.columns == 0 {
+		fmt.Fprintf(w, "%s<div class=\"columns\">\n", I(1))
+	} else {
+		fmt.Fprintf(w, "%s</div>\n", I(1))
+	}
+	article.columns++
 
-	default:
-		if strings.HasPrefix(name, "class:") {
-			extra := strings.Split(name[6:], ",")
-			return func(data, class string) (string, string, error) {
-				for _, e := range extra {
-					class += " "
-					class += e
-				}
-				return data, class, nil
-			}
+	width := 1
+	if val, ok := attrs.Options["width"]; ok {
+		var err error
+		width, err = strconv.Atoi(val)
+		if err != nil || width < 1 {
+			article.setError(fmt.Errorf("invalid column width: %s", val))
+			width = 1
 		}
-		return filterPassthrough
 	}
+	fmt.Fprintf(w, "%s<div class=\"column\" style=\"grid-column: span %d\">\n",
+		I(1), width)
 }
 
-func filterASCIIArt(data, class string) (string, string, error) {
-	return asciiart.Process(data), "ascii-art", nil
+// endColumns ends the slide columns.
+func (article *Article) endColumns(w io.Writer) {
+	if article.columns > 0 {
+		fmt.Fprintf(w, "%s</div>\n%s</div>\n", I(1), I(1))
+		article.columns = 0
+	}
 }
 
-func filterLinenumbers(data, class string) (string, string, error) {
-	var result string
-	lines := strings.Split(strings.TrimSpace(data), "\n")
-	var format string
-	if len(lines) > 9 {
-		format = "%2d %s\n"
+// endSlide ends the current slide and emits its speaker notes.
+func (article *Article) endSlide(w io.Writer) {
+	article.endColumns(w)
+	if article.Pagenum == 1 {
+		fmt.Fprintf(w, "%s</div>\n", I(1))
 	} else {
-		format = "%d %s\n"
+		fmt.Fprintf(w, "%s<span class=\"pagenumber\">%d</span>\n",
+			I(1), article.Pagenum)
 	}
-	for idx, line := range lines {
-		result += fmt.Sprintf(format, idx+1, line)
+	fmt.Fprintf(w, "%s</article>\n", I(0))
+	if len(article.slideNotes) > 0 {
+		fmt.Fprintf(w, "%s<aside class=\"notes\">\n%s%s</aside>\n",
+			I(0), article.slideNotes, I(0))
+		article.slideNotes = nil
 	}
-	return result, class, nil
-}
-
-func filterCenter(data, class string) (string, string, error) {
-	return data, class + " center", nil
-}
-
-func filterPassthrough(data, class string) (string, string, error) {
-	return data, class, nil
-}
-
-func filterPlain(data, class string) (string, string, error) {
-	return data, "code-plain", nil
+	io.WriteString(w, "\n")
 }
@@ -0,0 +1,222 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown/parser"
+)
+
+func TestRenderCodeLanguage(t *testing.T) {
+	tests := []struct {
+		info   string
+		expect []string
+	}{
+		{
+			info: "go,highlight:3",
+			expect: []string{
+				`<pre class="code language-go">`,
+				`<span class="hl">c` + "\n</span>",
+			},
+		},
+		{
+			info: "go highlight:3",
+			expect: []string{
+				`<pre class="code language-go">`,
+				`<span class="hl">c` + "\n</span>",
+			},
+		},
+		{
+			info: "ruby",
+			expect: []string{
+				`<pre class="code language-ruby">`,
+			},
+		},
+		{
+			info: "c++ linenumbers",
+			expect: []string{
+				`<pre class="code language-c++">`,
+			},
+		},
+		{
+			info: "go,class:a,b,highlight:3",
+			expect: []string{
+				`<pre class="code a b language-go">`,
+				`<span class="hl">c` + "\n</span>",
+			},
+		},
+		{
+			info: "highlight:1",
+			expect: []string{
+				`<pre class="code">`,
+				`<span class="hl">a` + "\n</span>",
+			},
+		},
+	}
+	for _, test := range tests {
+		article := new(Article)
+		var buf bytes.Buffer
+		article.renderCode(&buf, parseInfo([]byte(test.info)),
+			[]byte("a\nb\nc\n"))
+		if article.formatErr != nil {
+			t.Errorf("%s: %s", test.info, article.formatErr)
+			continue
+		}
+		for _, e := range test.expect {
+			if !strings.Contains(buf.String(), e) {
+				t.Errorf("%s: %q not in output:\n%s", test.info, e, buf.String())
+			}
+		}
+	}
+}
+
+func TestRenderCodeUnknownFilter(t *testing.T) {
+	for _, info := range []string{
+		"go,nosuch", "nosuch:3", "go ruby", "class:a,nosuch:3", "ruby:3",
+	} {
+		article := new(Article)
+		var buf bytes.Buffer
+		article.renderCode(&buf, parseInfo([]byte(info)), []byte("a\n"))
+		if article.formatErr == nil {
+			t.Errorf("%s: unknown filter accepted", info)
+		}
+	}
+}
+
+func TestRenderFigure(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+		reject string
+	}{
+		{
+			input:  `![Alt](x.png "Caption {#fig:a}")`,
+			expect: `<figure id="fig:a" class="figure">`,
+			reject: "<p>",
+		},
+		{
+			input:  `![Alt](x.png "Caption {#fig:a}") and text`,
+			expect: `<p><img src="x.png" alt="Alt" title="Caption"/> and text</p>`,
+			reject: "<figure",
+		},
+	}
+	for _, test := range tests {
+		article := NewArticle(parser.CommonExtensions)
+		data, err := article.format([]byte(test.input))
+		if err != nil {
+			t.Errorf("%q: %s", test.input, err)
+			continue
+		}
+		if !strings.Contains(string(data), test.expect) {
+			t.Errorf("%q: %q not in output:\n%s", test.input, test.expect, data)
+		}
+		if strings.Contains(string(data), test.reject) {
+			t.Errorf("%q: %q in output:\n%s", test.input, test.reject, data)
+		}
+	}
+}
+
+func formatPresentation(input string) (string, error) {
+	article := NewArticle(parser.CommonExtensions)
+	article.Settings.Article.Type = "presentation"
+	data, err := article.format([]byte(input))
+	return string(data), err
+}
+
+func TestRenderPresentation(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{
+			name:  "notes",
+			input: "## A\n\nText.\n\n```notes\nSay *this*.\n```\n\n## B\n",
+			expect: []string{
+				"</article>\n" + I(0) + "<aside class=\"notes\">\n" +
+					"<p>Say <em>this</em>.</p>\n" + I(0) + "</aside>\n",
+			},
+		},
+		{
+			name:  "last slide notes",
+			input: "## A\n\n```notes\nLast.\n```\n",
+			expect: []string{
+				"</article>\n" + I(0) + "<aside class=\"notes\">\n" +
+					"<p>Last.</p>\n" + I(0) + "</aside>\n",
+			},
+		},
+		{
+			name:  "columns",
+			input: "## A\n\n### Left {width=2}\n\nL\n\n### {width=1}\n\nR\n\n## B\n",
+			expect: []string{
+				"<div class=\"columns\">\n" + I(1) +
+					"<div class=\"column\" style=\"grid-column: span 2\">\n" +
+					I(2) + "<h4>Left</h4>\n<p>L</p>\n" + I(1) + "</div>\n",
+				"<div class=\"column\" style=\"grid-column: span 1\">\n",
+				"<p>R</p>\n" + I(1) + "</div>\n" + I(1) + "</div>\n" +
+					I(1) + "</div>\n" + I(0) + "</article>\n",
+			},
+		},
+		{
+			name:  "build slide",
+			input: "## A {build}\n\n- a\n- b\n\n## B\n\n- c\n",
+			expect: []string{
+				`<li class="step">a</li>`,
+				`<li class="step">b</li>`,
+				"<li>c</li>",
+			},
+		},
+		{
+			name:  "build list",
+			input: "## A\n\n- a\n\n<!-- build -->\n- b\n  - c\n- d\n",
+			expect: []string{
+				"<li>a</li>",
+				"<li class=\"step\">b\n",
+				`<li class="step">c</li>`,
+				`<li class="step">d</li>`,
+			},
+		},
+		{
+			name:  "attributes",
+			input: "## A {.dark #intro bg=#123 layout=section}\n\n## {T} value\n",
+			expect: []string{
+				`<article class="current dark layout-section" id="intro"` +
+					` style="background-color: #123">`,
+				"<h3>A</h3>",
+				"<h3>{T} value</h3>",
+			},
+		},
+	}
+	for _, test := range tests {
+		output, err := formatPresentation(test.input)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		for _, e := range test.expect {
+			if !strings.Contains(output, e) {
+				t.Errorf("%s: %q not in output:\n%s", test.name, e, output)
+			}
+		}
+	}
+
+	for _, input := range []string{
+		"## A {.dark unknown}\n",
+		"## A {layout=nosuch}\n",
+		"## A {bg=nosuch.png}\n",
+		"## A {bg=red;x:y}\n",
+		"## A\n\n### {width=0}\n",
+	} {
+		_, err := formatPresentation(input)
+		if err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
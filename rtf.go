@@ -11,10 +11,12 @@ import (
 	"io"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/parser"
+	"github.com/markkurossi/blog/asciiart"
 )
 
 var headingFonts = []int{
@@ -25,6 +27,60 @@ var headingFonts = []int{
 type RtfRenderer struct {
 	InText    bool
 	ListLevel int
+	Numbering Numbering
+	Captions  map[ast.Node]string
+	Err       error
+}
+
+// Number numbers the figures and listings of the document.
+func (rtf *RtfRenderer) Number(doc ast.Node) error {
+	rtf.Captions = make(map[ast.Node]string)
+
+	var err error
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering || err != nil {
+			return ast.GoToNext
+		}
+		var label, caption string
+		switch n := node.(type) {
+		case *ast.Image:
+			var id string
+			var ok bool
+			caption, id, ok = parseFigureTitle(string(n.Title))
+			if !ok {
+				return ast.GoToNext
+			}
+			label, err = rtf.Numbering.Figure(id)
+
+		case *ast.CodeBlock:
+			info := parseInfo(n.Info)
+			if !isListing(info) {
+				return ast.GoToNext
+			}
+			caption = info.Caption()
+			label, err = rtf.Numbering.Listing(info.ID)
+
+		default:
+			return ast.GoToNext
+		}
+		if len(caption) > 0 {
+			label += ": " + caption
+		}
+		rtf.Captions[node] = label
+		return ast.GoToNext
+	})
+	return err
+}
+
+func (rtf *RtfRenderer) writeCaption(w io.Writer, node ast.Node) {
+	caption, ok := rtf.Captions[node]
+	if ok {
+		fmt.Fprintf(w, "\n\\par\\par\\i %s\\i0\n", escapeRTF(caption))
+	}
+}
+
+func escapeRTF(val string) string {
+	return strings.ReplaceAll(val, "\\", "\\\\")
 }
 
 // RenderNode renders Markdown node to RTF.
@@ -62,13 +118,26 @@ func (rtf *RtfRenderer) RenderNode(w io.Writer, node ast.Node,
 		}
 		_, codeBlock := node.(*ast.CodeBlock)
 		if codeBlock {
+			rtf.writeCaption(w, node)
 			fmt.Fprintf(w, "\n\\par\\par\n")
+			if table := codeTable(node.(*ast.CodeBlock)); table != nil {
+				writeRTFTable(w, table)
+				break
+			}
 		}
 
 		leaf := node.AsLeaf()
 		if leaf != nil {
+			literal := string(leaf.Literal)
+			if !preserveNewlines {
+				var err error
+				literal, err = rtf.Numbering.ResolveText(literal)
+				if err != nil && rtf.Err == nil {
+					rtf.Err = err
+				}
+			}
 			var data []byte
-			for _, b := range leaf.Literal {
+			for _, b := range []byte(literal) {
 				switch b {
 				case '\\':
 					data = append(data, '\\')
@@ -88,6 +157,17 @@ func (rtf *RtfRenderer) RenderNode(w io.Writer, node ast.Node,
 			fmt.Fprintf(w, "\n\\par\n")
 		}
 
+	case *ast.Image:
+		if _, ok := rtf.Captions[node]; ok {
+			if entering {
+				rtf.writeCaption(w, node)
+			}
+			rtf.InText = true
+			return ast.SkipChildren
+		}
+		fmt.Printf(" - %T %v\n", node, entering)
+		nextInText = true
+
 	case *ast.Emph:
 		if entering {
 			fmt.Fprintf(w, "\\i ")
@@ -145,6 +225,62 @@ func (rtf *RtfRenderer) RenderNode(w io.Writer, node ast.Node,
 	return ast.GoToNext
 }
 
+// rtfCharWidth is the approximate character width in twips for the
+// table column widths.
+const rtfCharWidth = 120
+
+// codeTable returns the grid table of the ascii-table code block or
+// nil if the code block is not a grid table, see parseASCIITable.
+func codeTable(code *ast.CodeBlock) *asciiart.Table {
+	if !hasFlag(parseInfo(code.Info).Filters, "ascii-table") {
+		return nil
+	}
+	return parseASCIITable(string(code.Literal))
+}
+
+// writeRTFTable writes the table as an RTF table. The merged cells
+// are written with the horizontal and vertical cell merge controls.
+func writeRTFTable(w io.Writer, table *asciiart.Table) {
+	for row := 0; row < table.Rows; row++ {
+		fmt.Fprintf(w, "\\trowd\\trgaph108")
+		var x int
+		for col, width := range table.Columns {
+			c := table.CellAt(row, col)
+			if c.RowSpan > 1 {
+				if c.Row == row {
+					fmt.Fprintf(w, "\\clvmgf")
+				} else {
+					fmt.Fprintf(w, "\\clvmrg")
+				}
+			}
+			if c.ColSpan > 1 {
+				if c.Col == col {
+					fmt.Fprintf(w, "\\clmgf")
+				} else {
+					fmt.Fprintf(w, "\\clmrg")
+				}
+			}
+			x += (width + 1) * rtfCharWidth
+			fmt.Fprintf(w, "\\cellx%d", x)
+		}
+		fmt.Fprintf(w, "\n")
+		for col := range table.Columns {
+			c := table.CellAt(row, col)
+			fmt.Fprintf(w, "\\pard\\intbl ")
+			if c.Row == row && c.Col == col {
+				if table.Header(c) {
+					fmt.Fprintf(w, "\\b %s\\b0", escapeRTF(c.Text))
+				} else {
+					fmt.Fprintf(w, "%s", escapeRTF(c.Text))
+				}
+			}
+			fmt.Fprintf(w, "\\cell\n")
+		}
+		fmt.Fprintf(w, "\\row\n")
+	}
+	fmt.Fprintf(w, "\\pard\n")
+}
+
 // RenderHeader creates the RTF document header.
 func (rtf *RtfRenderer) RenderHeader(w io.Writer, ast ast.Node) {
 	fmt.Fprintf(w, `{\rtf1\ansi\ansicpg1252\deff0\deflang1033{\fonttbl{\f0\fswiss\fcharset0 %s;}}\viewkind4\uc1\pard\ql\f0`,
@@ -188,7 +324,18 @@ func (article *Article) ToRTF(file string) ([]byte, error) {
 		return nil, err
 	}
 	parser := parser.NewWithExtensions(article.Extensions)
-	doc := markdown.Parse(data, parser)
+	doc := markdown.Parse(braceFences(data), parser)
+	if err := article.includeCode(path.Dir(file), doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
 
-	return markdown.Render(doc, new(RtfRenderer)), nil
+	rtf := new(RtfRenderer)
+	if err := rtf.Number(doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	result := markdown.Render(doc, rtf)
+	if rtf.Err != nil {
+		return nil, fmt.Errorf("%s: %s", file, rtf.Err)
+	}
+	return result, nil
 }
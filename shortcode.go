@@ -0,0 +1,66 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Shortcodes have the syntax:
+//
+//	{{< name key="value" key2=value2 >}}
+//
+// The name selects the template snippet shortcodes/name.html from
+// the template directory and the key-value pairs are passed to the
+// snippet as HTML escaped template variables.
+var (
+	reShortcode = regexp.MustCompile(
+		`{{<\s*([a-zA-Z0-9_-]+)((?:\s+[a-zA-Z0-9_-]+=(?:"[^"]*"|[^\s">]+))*)\s*>}}`)
+	reShortcodeParam = regexp.MustCompile(
+		`([a-zA-Z0-9_-]+)=(?:"([^"]*)"|([^\s">]+))`)
+)
+
+// expandShortcodes expands the shortcodes of the argument text.
+func (article *Article) expandShortcodes(text string) (string, error) {
+	var err error
+
+	result := reShortcode.ReplaceAllStringFunc(text, func(code string) string {
+		if err != nil {
+			return code
+		}
+		m := reShortcode.FindStringSubmatch(code)
+		name := m[1]
+
+		t, ok := tmpl.Shortcodes[name]
+		if !ok {
+			err = fmt.Errorf("unknown shortcode '%s'", name)
+			return code
+		}
+		values := NewValues()
+		values.SetRaw(ValOutputDir, article.Values[ValOutputDir])
+		for _, p := range reShortcodeParam.FindAllStringSubmatch(m[2], -1) {
+			if len(p[2]) > 0 {
+				values.Set(p[1], p[2])
+			} else {
+				values.Set(p[1], p[3])
+			}
+		}
+		var buf bytes.Buffer
+		if err = t.Execute(&buf, values); err != nil {
+			err = fmt.Errorf("shortcode '%s': %s", name, err)
+			return code
+		}
+		return strings.TrimSpace(buf.String())
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
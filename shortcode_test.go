@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestExpandShortcodes(t *testing.T) {
+	saved := tmpl
+	defer func() {
+		tmpl = saved
+	}()
+	figure := template.Must(template.New("figure").Parse(
+		`<img src="{{.OutputDir}}{{.src}}" alt="{{.caption}}">`))
+	figure.Option("missingkey=error")
+	tmpl = &Template{
+		Shortcodes: map[string]*template.Template{
+			"figure": figure,
+		},
+	}
+	article := NewArticle(0)
+	article.Values[ValOutputDir] = "../"
+
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  `a {{< figure src="x.png" caption="A <b> & c" >}} b`,
+			output: `a <img src="../x.png" alt="A &lt;b&gt; &amp; c"> b`,
+		},
+		{
+			input:  `{{<figure src=x.png caption="">}}`,
+			output: `<img src="../x.png" alt="">`,
+		},
+		{
+			input:  `no {{ shortcodes }} here`,
+			output: `no {{ shortcodes }} here`,
+		},
+	}
+	for _, test := range tests {
+		output, err := article.expandShortcodes(test.input)
+		if err != nil {
+			t.Errorf("%s: %s", test.input, err)
+			continue
+		}
+		if output != test.output {
+			t.Errorf("%s: got %q, expected %q", test.input, output, test.output)
+		}
+	}
+
+	for _, input := range []string{
+		`{{< nosuch >}}`,
+		`{{< figure src="x.png" >}}`,
+	} {
+		_, err := article.expandShortcodes(input)
+		if err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
+
+func TestMapSourceCode(t *testing.T) {
+	input := strings.Join([]string{
+		"Text [[a]] and `[[b]]`.",
+		"",
+		"    indented [[c]]",
+		"",
+		"```",
+		"fenced [[d]]",
+		"```",
+		"- item [[e]]",
+		"",
+		"    item text [[f]]",
+		"",
+		"Last [[g]].",
+	}, "\n")
+	output, err := mapSource([]byte(input), func(text string) (string, error) {
+		return strings.ReplaceAll(text, "[[", "<<"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"Text <<a]] and `[[b]]`.",
+		"",
+		"    indented [[c]]",
+		"",
+		"```",
+		"fenced [[d]]",
+		"```",
+		"- item <<e]]",
+		"",
+		"    item text <<f]]",
+		"",
+		"Last <<g]].",
+	}, "\n")
+	if string(output) != expected {
+		t.Errorf("mapSource:\n%s\nexpected:\n%s", output, expected)
+	}
+}
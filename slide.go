@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SlideLayouts define the named slide layouts.
+var SlideLayouts = map[string]bool{
+	"title":      true,
+	"section":    true,
+	"image-only": true,
+}
+
+var (
+	reColor       = regexp.MustCompile(`^[#a-zA-Z0-9(),.% ]+$`)
+	reImageRef    = regexp.MustCompile(`^[^'"()\s]+$`)
+	imageSuffixes = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp"}
+)
+
+// slideAttrs returns the HTML attributes for the slide article
+// element. The slide heading attributes can specify extra classes, a
+// background image or color, and a named layout:
+//
+//	## Title {.title-slide bg=photo.png layout=title}
+func (article *Article) slideAttrs(attrs *CodeInfo) (string, error) {
+	classes := []string{className(article.Pagenum)}
+	var style string
+
+	for _, flag := range attrs.Filters {
+		switch {
+		case flag == "build":
+		case strings.HasPrefix(flag, ".") && len(flag) > 1:
+			classes = append(classes, flag[1:])
+		default:
+			return "", fmt.Errorf("unknown attribute '%s'", flag)
+		}
+	}
+	var keys []string
+	for k := range attrs.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := attrs.Options[k]
+		switch k {
+		case "layout":
+			if !SlideLayouts[v] {
+				return "", fmt.Errorf("unknown layout '%s'", v)
+			}
+			classes = append(classes, "layout-"+v)
+
+		case "bg":
+			if isImage(v) {
+				if !reImageRef.MatchString(v) {
+					return "", fmt.Errorf("invalid background image '%s'", v)
+				}
+				if !isExternal(v) {
+					if article.Assets == nil {
+						return "", fmt.Errorf("background image '%s' not found",
+							v)
+					}
+					_, err := os.Stat(path.Join(article.Assets.Dir(), v))
+					if err != nil {
+						return "", fmt.Errorf("background image '%s' not found",
+							v)
+					}
+				}
+				classes = append(classes, "background")
+				style = fmt.Sprintf("background-image: url('%s')", v)
+			} else if reColor.MatchString(v) {
+				style = fmt.Sprintf("background-color: %s", v)
+			} else {
+				return "", fmt.Errorf("invalid background '%s'", v)
+			}
+
+		default:
+			return "", fmt.Errorf("unknown attribute '%s'", k)
+		}
+	}
+
+	result := fmt.Sprintf(` class="%s"`,
+		html.EscapeString(strings.Join(classes, " ")))
+	if len(attrs.ID) > 0 {
+		result += idAttr(attrs.ID)
+	}
+	if len(style) > 0 {
+		result += fmt.Sprintf(` style="%s"`, style)
+	}
+	return result, nil
+}
+
+func isImage(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, suffix := range imageSuffixes {
+		if ext == suffix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+//
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Standalone presentations have their scripts, stylesheets, fonts,
+// and images inlined into the HTML file.
+var (
+	reScript     = regexp.MustCompile(`<script src="([^"]+)"></script>`)
+	reStylesheet = regexp.MustCompile(`<link href="([^"]+)" rel="stylesheet"[^>]*>`)
+	reIcon       = regexp.MustCompile(`(<link rel="icon" href=)"([^"]+)"`)
+	reSrc        = regexp.MustCompile(`(<img[^>]*\ssrc=)"([^"]+)"`)
+	reSrcset     = regexp.MustCompile(`\s(?:srcset|sizes)="[^"]*"`)
+	reStyleAttr  = regexp.MustCompile(`\sstyle="[^"]*"`)
+	reURL        = regexp.MustCompile(`url\(\s*(['"]?)([^'")]+)(['"]?)\s*\)`)
+	reFontSrc    = regexp.MustCompile(
+		`src:\s*(url\([^)]*\)\s*format\('woff2'\))\s*,[^;]*;`)
+)
+
+var fontTypes = map[string]string{
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+}
+
+// GenerateStandalone creates a self-contained version of the
+// generated article HTML file.
+func (article *Article) GenerateStandalone(dir string) error {
+	input := path.Join(dir, article.OutputName())
+	output := path.Join(dir, article.outputName("-standalone.html"))
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	result, err := inlineHTML(path.Dir(input), string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %s", input, err)
+	}
+	err = os.WriteFile(output, []byte(result), 0644)
+	if err != nil {
+		return err
+	}
+	log.Printf("%s\t=> %s\n", input, output)
+	return nil
+}
+
+func inlineHTML(dir, data string) (string, error) {
+	var err error
+
+	replace := func(re *regexp.Regexp, data string,
+		f func(m []string) (string, error)) string {
+
+		return re.ReplaceAllStringFunc(data, func(a string) string {
+			if err != nil {
+				return a
+			}
+			var result string
+			result, err = f(re.FindStringSubmatch(a))
+			return result
+		})
+	}
+
+	// Inline the HTML references before the scripts and stylesheets.
+	data = replace(reIcon, data, func(m []string) (string, error) {
+		uri, err := dataURI(dir, m[2])
+		return fmt.Sprintf(`%s"%s"`, m[1], uri), err
+	})
+	data = reSrcset.ReplaceAllString(data, "")
+	data = replace(reSrc, data, func(m []string) (string, error) {
+		uri, err := dataURI(dir, m[2])
+		return fmt.Sprintf(`%s"%s"`, m[1], uri), err
+	})
+	data = replace(reStyleAttr, data, func(m []string) (string, error) {
+		return inlineCSS(dir, m[0])
+	})
+	data = replace(reScript, data, func(m []string) (string, error) {
+		if isExternal(m[1]) {
+			return m[0], nil
+		}
+		script, err := os.ReadFile(path.Join(dir, m[1]))
+		if err != nil {
+			return "", err
+		}
+		return "<script>\n" +
+			strings.ReplaceAll(string(script), "</script", `<\/script`) +
+			"\n</script>", nil
+	})
+	data = replace(reStylesheet, data, func(m []string) (string, error) {
+		if isExternal(m[1]) {
+			return m[0], nil
+		}
+		file := path.Join(dir, m[1])
+		css, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		inlined, err := inlineCSS(path.Dir(file), string(css))
+		if err != nil {
+			return "", err
+		}
+		return "<style>\n" + inlined + "\n</style>", nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return data, nil
+}
+
+// inlineCSS inlines the url() references of the CSS data. The font
+// sources are reduced to their WOFF2 versions.
+func inlineCSS(dir, data string) (string, error) {
+	data = reFontSrc.ReplaceAllString(data, "src: $1;")
+
+	var err error
+	result := reURL.ReplaceAllStringFunc(data, func(a string) string {
+		if err != nil {
+			return a
+		}
+		m := reURL.FindStringSubmatch(a)
+		var uri string
+		uri, err = dataURI(dir, m[2])
+		return fmt.Sprintf("url('%s')", uri)
+	})
+	return result, err
+}
+
+// dataURI returns the file as a data URI. External references are
+// returned as-is.
+func dataURI(dir, ref string) (string, error) {
+	if isExternal(ref) {
+		return ref, nil
+	}
+	file := path.Join(dir, ref)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	ext := strings.ToLower(path.Ext(file))
+	mimeType, ok := fontTypes[ext]
+	if !ok {
+		mimeType = mime.TypeByExtension(ext)
+	}
+	if len(mimeType) == 0 {
+		mimeType = "application/octet-stream"
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mimeType,
+		base64.StdEncoding.EncodeToString(data)), nil
+}
+
+func isExternal(ref string) bool {
+	return strings.Contains(ref, ":") || strings.HasPrefix(ref, "//") ||
+		strings.HasPrefix(ref, "#")
+}
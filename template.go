@@ -18,14 +18,20 @@ const (
 	TmplIndex        = "index.html"
 	TmplArticle      = "article.html"
 	TmplPresentation = "presentation.html"
+	TmplHandout      = "handout.html"
 	TmplTag          = "tag.html"
 )
 
+// ShortcodeDir is the template subdirectory containing the shortcode
+// template snippets.
+const ShortcodeDir = "shortcodes"
+
 // Template defines blog output template.
 type Template struct {
-	Dir       string
-	Templates map[string]*template.Template
-	Assets    *Assets
+	Dir        string
+	Templates  map[string]*template.Template
+	Shortcodes map[string]*template.Template
+	Assets     *Assets
 }
 
 func loadTemplate(dir string) (tmpl *Template, err error) {
@@ -42,19 +48,30 @@ func loadTemplate(dir string) (tmpl *Template, err error) {
 	}
 
 	tmpl = &Template{
-		Dir:       dir,
-		Templates: make(map[string]*template.Template),
-		Assets:    NewAssets(dir),
+		Dir:        dir,
+		Templates:  make(map[string]*template.Template),
+		Shortcodes: make(map[string]*template.Template),
+		Assets:     NewAssets(dir),
 	}
 
 	for _, file := range files {
 		fn := file.Name()
 
-		if file.IsDir() {
+		if file.IsDir() && fn == ShortcodeDir {
+			err = tmpl.loadShortcodes(path.Join(dir, fn))
+			if err != nil {
+				return nil, err
+			}
+		} else if file.IsDir() {
 			err = tmpl.Assets.AddDir(path.Join(dir, fn))
 			if err != nil {
 				return nil, err
 			}
+		} else if fn == FiltersFile {
+			err = loadFilters(path.Join(dir, fn))
+			if err != nil {
+				return nil, err
+			}
 		} else if strings.HasSuffix(fn, "~") {
 		} else if strings.HasSuffix(fn, ".html") {
 			t, err := template.ParseFiles(path.Join(dir, fn))
@@ -72,6 +89,33 @@ func loadTemplate(dir string) (tmpl *Template, err error) {
 	return
 }
 
+func (tmpl *Template) loadShortcodes(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	files, err := f.ReadDir(0)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		fn := file.Name()
+		if file.IsDir() || !strings.HasSuffix(fn, ".html") {
+			continue
+		}
+		t, err := template.ParseFiles(path.Join(dir, fn))
+		if err != nil {
+			return err
+		}
+		t.Option("missingkey=error")
+
+		tmpl.Shortcodes[strings.TrimSuffix(fn, ".html")] = t
+	}
+	return nil
+}
+
 // CopyAssets copies the template assets to the argument directory.
 func (tmpl *Template) CopyAssets(dir string) error {
 	return tmpl.Assets.Copy(dir)
@@ -0,0 +1,108 @@
+// Copyright (c) 2024 Markku Rossi
+//
+// All rights reserved.
+//
+// Speaker notes and presenter view. The presenter view is the same
+// presentation page opened with the ?presenter query. The slides.js
+// keeps the audience and presenter windows in sync through the local
+// storage.
+
+var notesEnabled = true;
+
+var presenterWindow = null;
+var presenterStart = null;
+
+function isPresenter() {
+  return location.search.indexOf('presenter') !== -1;
+}
+
+function destSlideKey() {
+  return 'destSlide:' + location.pathname;
+}
+
+function destStepKey() {
+  return 'destStep:' + location.pathname;
+}
+
+function toggleNotesWindow() {
+  if (isPresenter()) {
+    return;
+  }
+  if (presenterWindow && !presenterWindow.closed) {
+    presenterWindow.close();
+    presenterWindow = null;
+    return;
+  }
+  presenterWindow = window.open(
+    location.pathname + '?presenter#' + (curSlide + 1),
+    'presenter:' + location.pathname,
+    'width=1200,height=800'
+  );
+}
+
+function updateNotes() {
+  if (!isPresenter()) {
+    return;
+  }
+  var el = document.getElementById('presenter-slide');
+  if (el) {
+    el.textContent = curSlide + 1 + ' / ' + slideEls.length;
+  }
+}
+
+// The playground code is not synchronized between the windows.
+function updatePlay(e) {}
+function updatePlayStorage(action, index, e) {}
+
+function formatTime(seconds) {
+  var h = Math.floor(seconds / 3600);
+  var m = Math.floor(seconds / 60) % 60;
+  var s = seconds % 60;
+  return [h, m, s]
+    .map(function(v) {
+      return v < 10 ? '0' + v : '' + v;
+    })
+    .join(':');
+}
+
+function updateTimer() {
+  var el = document.getElementById('presenter-timer');
+  el.textContent = formatTime(Math.floor((Date.now() - presenterStart) / 1000));
+}
+
+function setupPresenter() {
+  if (!isPresenter()) {
+    return;
+  }
+  document.body.classList.add('presenter');
+
+  var el = document.createElement('div');
+  el.id = 'presenter-status';
+  el.innerHTML =
+    '<span id="presenter-slide"></span>' +
+    '<span id="presenter-timer" title="Click to reset"></span>';
+  document.body.appendChild(el);
+
+  presenterStart = Date.now();
+  document
+    .getElementById('presenter-timer')
+    .addEventListener('click', function() {
+      presenterStart = Date.now();
+      updateTimer();
+    });
+  updateTimer();
+  window.setInterval(updateTimer, 1000);
+
+  // The slides.js calls updateNotes only for changes from the other
+  // window.
+  document.addEventListener(
+    'keydown',
+    function() {
+      window.setTimeout(updateNotes, 0);
+    },
+    false
+  );
+  updateNotes();
+}
+
+document.addEventListener('DOMContentLoaded', setupPresenter, false);
@@ -206,24 +206,67 @@ function updateSlides() {
 
 function prevSlide() {
   hideHelpText();
-  if (curSlide > 0) {
+  var shown = shownSteps(curSlide);
+  if (shown > 0) {
+    setSteps(curSlide, shown - 1);
+  } else if (curSlide > 0) {
     curSlide--;
+    setSteps(curSlide, getSteps(curSlide).length);
 
     updateSlides();
   }
 
-  if (notesEnabled) localStorage.setItem(destSlideKey(), curSlide);
+  storeSlidePosition();
 }
 
 function nextSlide() {
   hideHelpText();
-  if (curSlide < slideEls.length - 1) {
+  var shown = shownSteps(curSlide);
+  if (shown < getSteps(curSlide).length) {
+    setSteps(curSlide, shown + 1);
+  } else if (curSlide < slideEls.length - 1) {
     curSlide++;
+    setSteps(curSlide, 0);
 
     updateSlides();
   }
 
-  if (notesEnabled) localStorage.setItem(destSlideKey(), curSlide);
+  storeSlidePosition();
+}
+
+function storeSlidePosition() {
+  if (!notesEnabled) return;
+  localStorage.setItem(destStepKey(), shownSteps(curSlide));
+  localStorage.setItem(destSlideKey(), curSlide);
+}
+
+/* Incremental reveal */
+
+function getSteps(no) {
+  var el = getSlideEl(no);
+  if (!el) {
+    return [];
+  }
+  return el.querySelectorAll('.step');
+}
+
+function shownSteps(no) {
+  var el = getSlideEl(no);
+  if (!el) {
+    return 0;
+  }
+  return el.querySelectorAll('.step.shown').length;
+}
+
+function setSteps(no, count) {
+  var steps = getSteps(no);
+  for (var i = 0; i < steps.length; i++) {
+    if (i < count) {
+      steps[i].classList.add('shown');
+    } else {
+      steps[i].classList.remove('shown');
+    }
+  }
 }
 
 /* Slide events */
@@ -622,13 +665,14 @@ function updateOtherWindow(e) {
   var isRemoveStorageEvent = !e.newValue;
   if (isRemoveStorageEvent) return;
 
-  var destSlide = localStorage.getItem(destSlideKey());
+  var destSlide = parseInt(localStorage.getItem(destSlideKey()));
   while (destSlide > curSlide) {
     nextSlide();
   }
   while (destSlide < curSlide) {
     prevSlide();
   }
+  setSteps(curSlide, parseInt(localStorage.getItem(destStepKey())) || 0);
 
   updatePlay(e);
   updateNotes();